@@ -0,0 +1,100 @@
+package eureka
+
+import (
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const DefaultAmazonMetadataUrl = "http://169.254.169.254/latest/meta-data/"
+
+type AmazonInfoCollector struct {
+	client      *http.Client
+	metadataUrl string
+}
+
+func NewAmazonInfoCollector(metadataUrl string) *AmazonInfoCollector {
+	if metadataUrl == "" {
+		metadataUrl = DefaultAmazonMetadataUrl
+	}
+	if !strings.HasSuffix(metadataUrl, "/") {
+		metadataUrl = metadataUrl + "/"
+	}
+	return &AmazonInfoCollector{
+		client: &http.Client{
+			Timeout: 5 * time.Second,
+		},
+		metadataUrl: metadataUrl,
+	}
+}
+
+func (collector *AmazonInfoCollector) Collect() (*AmazonInfo, error) {
+	amazonInfo := &AmazonInfo{}
+
+	fields := []struct {
+		key   string
+		value *string
+	}{
+		{"instance-id", &amazonInfo.InstanceId},
+		{"ami-id", &amazonInfo.AmiId},
+		{"ami-launch-index", &amazonInfo.AmiLaunchIndex},
+		{"ami-manifest-path", &amazonInfo.AmiManifestPath},
+		{"instance-type", &amazonInfo.InstanceType},
+		{"placement/availability-zone", &amazonInfo.AvailabilityZone},
+		{"public-hostname", &amazonInfo.PublicHostname},
+		{"public-ipv4", &amazonInfo.PublicIpv4},
+		{"local-hostname", &amazonInfo.LocalHostname},
+		{"local-ipv4", &amazonInfo.LocalIpv4},
+		{"mac", &amazonInfo.Mac},
+	}
+
+	for _, field := range fields {
+		value, err := collector.fetch(field.key)
+		if err != nil {
+			return amazonInfo, err
+		}
+		*field.value = value
+	}
+
+	if amazonInfo.Mac != "" {
+		vpcId, err := collector.fetch("network/interfaces/macs/" + amazonInfo.Mac + "/vpc-id")
+		if err != nil {
+			return amazonInfo, err
+		}
+		amazonInfo.VpcId = vpcId
+	}
+
+	if amazonInfo.InstanceId == "" {
+		return amazonInfo, errors.New("eureka: instance id could not be resolved from " + collector.metadataUrl)
+	}
+	return amazonInfo, nil
+}
+
+func (collector *AmazonInfoCollector) fetch(key string) (string, error) {
+	resp, err := collector.client.Get(collector.metadataUrl + key)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	// not every key is available, public ones are missing inside a vpc for example
+	if resp.StatusCode == http.StatusNotFound {
+		return "", nil
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return "", &ResponseError{
+			StatusCode: resp.StatusCode,
+			Message:    http.StatusText(resp.StatusCode),
+		}
+	}
+
+	var body []byte
+	body, err = ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(body)), nil
+}
@@ -0,0 +1,243 @@
+package eureka
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func newAmazonMetadataServer(metadata map[string]string, failingKey string, requests *int32) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		atomic.AddInt32(requests, 1)
+		key := strings.TrimPrefix(request.URL.Path, "/latest/meta-data/")
+		if key == failingKey {
+			writer.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		value, ok := metadata[key]
+		if !ok {
+			writer.WriteHeader(http.StatusNotFound)
+			return
+		}
+		_, _ = writer.Write([]byte(value + "\n"))
+	}))
+}
+
+func TestAmazonInfoCollector_Collect(t *testing.T) {
+	metadata := map[string]string{
+		"instance-id":                 "i-1234",
+		"ami-id":                      "ami-5678",
+		"instance-type":               "t3.micro",
+		"placement/availability-zone": "us-east-1a",
+		"local-hostname":              "ip-10-0-0-1.ec2.internal",
+		"local-ipv4":                  "10.0.0.1",
+		"mac":                         "0e:00:00:00:00:01",
+		"network/interfaces/macs/0e:00:00:00:00:01/vpc-id": "vpc-9abc",
+	}
+
+	testCases := []struct {
+		name       string
+		metadata   map[string]string
+		failingKey string
+		expected   AmazonInfo
+		expectErr  bool
+	}{
+		{
+			name:     "all keys",
+			metadata: metadata,
+			expected: AmazonInfo{
+				InstanceId:       "i-1234",
+				AmiId:            "ami-5678",
+				InstanceType:     "t3.micro",
+				AvailabilityZone: "us-east-1a",
+				LocalHostname:    "ip-10-0-0-1.ec2.internal",
+				LocalIpv4:        "10.0.0.1",
+				Mac:              "0e:00:00:00:00:01",
+				VpcId:            "vpc-9abc",
+			},
+		},
+		{
+			name:     "missing instance id",
+			metadata: map[string]string{"ami-id": "ami-5678"},
+			expected: AmazonInfo{
+				AmiId: "ami-5678",
+			},
+			expectErr: true,
+		},
+		{
+			name:       "failing key",
+			metadata:   metadata,
+			failingKey: "instance-type",
+			expected: AmazonInfo{
+				InstanceId: "i-1234",
+				AmiId:      "ami-5678",
+			},
+			expectErr: true,
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			var requests int32
+			server := newAmazonMetadataServer(testCase.metadata, testCase.failingKey, &requests)
+			defer server.Close()
+
+			amazonInfo, err := NewAmazonInfoCollector(server.URL + "/latest/meta-data").Collect()
+			if (err != nil) != testCase.expectErr {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if *amazonInfo != testCase.expected {
+				t.Errorf("expected %+v, got %+v", testCase.expected, *amazonInfo)
+			}
+		})
+	}
+}
+
+func TestAmazonInstanceInfoContributor_Contribute(t *testing.T) {
+	testCases := []struct {
+		name             string
+		metadataMap      map[string]string
+		failingKey       string
+		expectedZone     string
+		expectedRequests int32
+		expectErr        bool
+	}{
+		{
+			name:             "zone from the metadata",
+			expectedZone:     "us-east-1a",
+			expectedRequests: 11,
+		},
+		{
+			name:             "configured zone",
+			metadataMap:      map[string]string{ZoneKey: "configured"},
+			expectedZone:     "configured",
+			expectedRequests: 11,
+		},
+		{
+			name:             "collected again after a failure",
+			failingKey:       "mac",
+			expectedZone:     "us-east-1a",
+			expectedRequests: 22,
+			expectErr:        true,
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			var requests int32
+			server := newAmazonMetadataServer(map[string]string{
+				"instance-id":                 "i-1234",
+				"placement/availability-zone": "us-east-1a",
+			}, testCase.failingKey, &requests)
+			defer server.Close()
+
+			contributor := newAmazonInstanceInfoContributor(InstanceProperties{
+				AmazonMetadataUrl: server.URL + "/latest/meta-data/",
+				MetadataMap:       testCase.metadataMap,
+			})
+
+			for attempt := 0; attempt < 2; attempt++ {
+				instanceInfo := &InstanceInfo{
+					Metadata: MetadataMap{},
+				}
+				for key, value := range testCase.metadataMap {
+					instanceInfo.Metadata[key] = value
+				}
+
+				err := contributor.Contribute(instanceInfo)
+				if (err != nil) != testCase.expectErr {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				if instanceInfo.DataCenterInfo.Name != DataCenterAmazon || instanceInfo.DataCenterInfo.Class != AmazonInfoClass {
+					t.Errorf("unexpected data center info: %+v", instanceInfo.DataCenterInfo)
+				}
+				if zone := instanceInfo.Metadata[ZoneKey]; zone != testCase.expectedZone {
+					t.Errorf("expected zone %q, got %q", testCase.expectedZone, zone)
+				}
+			}
+
+			if requests := atomic.LoadInt32(&requests); requests != testCase.expectedRequests {
+				t.Errorf("expected %d requests, got %d", testCase.expectedRequests, requests)
+			}
+		})
+	}
+}
+
+func TestDefaultInstanceInfoProvider_RetriesAmazonInfo(t *testing.T) {
+	testCases := []struct {
+		name              string
+		failFirst         bool
+		retryInterval     time.Duration
+		expectedZone      string
+		expectedRequests  int32
+		expectRebuiltInfo bool
+	}{
+		{
+			name:              "retried after a failure",
+			failFirst:         true,
+			expectedZone:      "us-east-1a",
+			expectedRequests:  6 + 11,
+			expectRebuiltInfo: true,
+		},
+		{
+			name:             "not retried before the retry interval",
+			failFirst:        true,
+			retryInterval:    time.Hour,
+			expectedRequests: 6,
+		},
+		{
+			name:             "not collected again once complete",
+			expectedZone:     "us-east-1a",
+			expectedRequests: 11,
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			var requests int32
+			failing := int32(0)
+			if testCase.failFirst {
+				failing = 1
+			}
+			server := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+				atomic.AddInt32(&requests, 1)
+				key := strings.TrimPrefix(request.URL.Path, "/latest/meta-data/")
+				switch {
+				case key == "placement/availability-zone" && atomic.LoadInt32(&failing) == 1:
+					writer.WriteHeader(http.StatusInternalServerError)
+				case key == "placement/availability-zone":
+					_, _ = writer.Write([]byte("us-east-1a"))
+				case key == "instance-id":
+					_, _ = writer.Write([]byte("i-1234"))
+				default:
+					writer.WriteHeader(http.StatusNotFound)
+				}
+			}))
+			defer server.Close()
+
+			instanceProperties := newValidInstanceProperties()
+			instanceProperties.DataCenterInfo.Name = DataCenterAmazon
+			instanceProperties.AmazonMetadataUrl = server.URL + "/latest/meta-data/"
+			provider := newDefaultInstanceInfoProvider(instanceProperties, *newClientProperties(), nil, nil)
+			provider.contributionRetryInterval = testCase.retryInterval
+
+			first := provider.GetInstanceInfo()
+			atomic.StoreInt32(&failing, 0)
+			second := provider.GetInstanceInfo()
+
+			if zone := second.DataCenterInfo.Metadata.AvailabilityZone; zone != testCase.expectedZone {
+				t.Errorf("expected the availability zone %q, got %q", testCase.expectedZone, zone)
+			}
+			if requests := atomic.LoadInt32(&requests); requests != testCase.expectedRequests {
+				t.Errorf("expected %d requests, got %d", testCase.expectedRequests, requests)
+			}
+			// a rebuilt info is marked dirty, so the completed metadata is sent to the server
+			if rebuilt := first.LastDirtyTimestamp != second.LastDirtyTimestamp; rebuilt != testCase.expectRebuiltInfo {
+				t.Errorf("expected the info to be rebuilt: %v", testCase.expectRebuiltInfo)
+			}
+		})
+	}
+}
@@ -0,0 +1,99 @@
+package eureka
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+type BackupRegistry interface {
+	Load() (*Applications, error)
+	Save(applications *Applications) error
+}
+
+type registryBackup struct {
+	SavedAt      time.Time     `json:"savedAt"`
+	Applications *Applications `json:"applications"`
+}
+
+type FileBackupRegistry struct {
+	path string
+}
+
+func NewFileBackupRegistry(path string) FileBackupRegistry {
+	return FileBackupRegistry{
+		path,
+	}
+}
+
+func (backupRegistry FileBackupRegistry) Load() (*Applications, error) {
+	content, err := ioutil.ReadFile(backupRegistry.path)
+	if err != nil {
+		return nil, err
+	}
+
+	backup := &registryBackup{}
+	err = json.Unmarshal(content, backup)
+	if err != nil {
+		return nil, err
+	}
+
+	if backup.Applications == nil {
+		backup.Applications = &Applications{}
+	}
+	return backup.Applications, nil
+}
+
+func (backupRegistry FileBackupRegistry) Save(applications *Applications) error {
+	content, err := json.Marshal(&registryBackup{
+		SavedAt:      time.Now(),
+		Applications: applications,
+	})
+	if err != nil {
+		return err
+	}
+
+	dir := filepath.Dir(backupRegistry.path)
+	if err = os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+
+	// the snapshot is written next to the target and renamed, so a crash never leaves a partial file behind
+	var tempFile *os.File
+	tempFile, err = ioutil.TempFile(dir, filepath.Base(backupRegistry.path)+".tmp")
+	if err != nil {
+		return err
+	}
+	tempPath := tempFile.Name()
+
+	if _, err = tempFile.Write(content); err == nil {
+		err = tempFile.Sync()
+	}
+
+	if closeErr := tempFile.Close(); err == nil {
+		err = closeErr
+	}
+
+	if err == nil {
+		err = os.Rename(tempPath, backupRegistry.path)
+	}
+
+	if err != nil {
+		_ = os.Remove(tempPath)
+	}
+	return err
+}
+
+func (applications *Applications) digest() string {
+	content, err := json.Marshal(applications)
+	if err != nil {
+		// an empty digest is never skipped, the registry is saved again
+		return ""
+	}
+	sum := sha256.Sum256(content)
+	return hex.EncodeToString(sum[:])
+}
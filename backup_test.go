@@ -0,0 +1,104 @@
+package eureka
+
+import (
+	"sync"
+	"testing"
+)
+
+type backupRecorder struct {
+	saved   []*Applications
+	savedMu sync.Mutex
+}
+
+func (recorder *backupRecorder) Load() (*Applications, error) {
+	recorder.savedMu.Lock()
+	defer recorder.savedMu.Unlock()
+	if len(recorder.saved) == 0 {
+		return &Applications{}, nil
+	}
+	return recorder.saved[len(recorder.saved)-1], nil
+}
+
+func (recorder *backupRecorder) Save(applications *Applications) error {
+	recorder.savedMu.Lock()
+	defer recorder.savedMu.Unlock()
+	recorder.saved = append(recorder.saved, applications.copy())
+	return nil
+}
+
+func (recorder *backupRecorder) getSaved() []*Applications {
+	recorder.savedMu.Lock()
+	defer recorder.savedMu.Unlock()
+	return append([]*Applications(nil), recorder.saved...)
+}
+
+func TestRegistryCache_SavesBackup(t *testing.T) {
+	newApplications := func(instanceIds ...string) *Applications {
+		application := Application{Name: "ORDERS"}
+		for _, instanceId := range instanceIds {
+			application.Instances = append(application.Instances, newTestInstance("ORDERS", instanceId, InstanceStatusUp, ""))
+		}
+		return &Applications{Applications: []Application{application}}
+	}
+
+	server := newFakeEurekaServer(newApplications("orders-1"))
+	defer server.Close()
+
+	clientProperties := newClientProperties()
+	clientProperties.ServiceUrl = map[string]string{DefaultZone: server.serviceUrl()}
+	clientProperties.DisableDelta = true
+
+	recorder := &backupRecorder{}
+	registryCache := newRegistryCache(NewDefaultHttpClient(server.serviceUrl()), *clientProperties, nil, nil)
+	registryCache.SetBackupRegistry(recorder)
+
+	testCases := []struct {
+		name                string
+		applications        *Applications
+		expectedSaves       int
+		expectedInstanceIds []string
+	}{
+		{
+			name:                "first fetch",
+			applications:        newApplications("orders-1"),
+			expectedSaves:       1,
+			expectedInstanceIds: []string{"orders-1"},
+		},
+		{
+			name:                "same registry",
+			applications:        newApplications("orders-1"),
+			expectedSaves:       1,
+			expectedInstanceIds: []string{"orders-1"},
+		},
+		{
+			name:                "replaced instance with the same hashcode",
+			applications:        newApplications("orders-2"),
+			expectedSaves:       2,
+			expectedInstanceIds: []string{"orders-2"},
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			server.setApplications(testCase.applications)
+			if err := registryCache.Refresh(); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			saved := recorder.getSaved()
+			if len(saved) != testCase.expectedSaves {
+				t.Fatalf("expected %d saves, got %d", testCase.expectedSaves, len(saved))
+			}
+
+			instances := saved[len(saved)-1].GetApplication("ORDERS").Instances
+			if len(instances) != len(testCase.expectedInstanceIds) {
+				t.Fatalf("expected the instances %v in the backup, got %v", testCase.expectedInstanceIds, instances)
+			}
+			for index, instance := range instances {
+				if instance.InstanceId != testCase.expectedInstanceIds[index] {
+					t.Errorf("expected the instances %v in the backup, got %v", testCase.expectedInstanceIds, instances)
+				}
+			}
+		})
+	}
+}
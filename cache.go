@@ -0,0 +1,481 @@
+package eureka
+
+import (
+	"context"
+	"math/rand"
+	"sync"
+	"time"
+)
+
+const (
+	defaultRegistryFetchInterval = 30 * time.Second
+	maxHashcodeMismatches        = 10
+)
+
+type HashcodeMismatch struct {
+	Time           time.Time `json:"time" xml:"time"`
+	LocalHashcode  string    `json:"localHashcode" xml:"localHashcode"`
+	RemoteHashcode string    `json:"remoteHashcode" xml:"remoteHashcode"`
+}
+
+type RegistryCache struct {
+	httpClient            ContextHttpClient
+	clientProperties      ClientProperties
+	applications          *Applications
+	fetchedApplications   *Applications
+	preservedApplications *Applications
+	remoteApplications    map[string]*Applications
+	lastFetchTime         time.Time
+	firstAttemptTime      time.Time
+	lastDeltaVersion      string
+	appsHashcode          string
+	hashcodeMismatches    []HashcodeMismatch
+	consecutiveFailures   int
+	stale                 bool
+	backupRegistry        BackupRegistry
+	backupDigest          string
+	applicationsMu        sync.RWMutex
+	fetchMu               sync.Mutex
+	lifecycleMu           sync.Mutex
+	stopCh                chan struct{}
+	interval              time.Duration
+	metrics               MetricsRecorder
+	logger                Logger
+	reportedApps          map[string]bool
+	heldApps              map[string]time.Time
+	eventListeners        []EventListener
+	propertyFilters       []InstanceFilter
+	instanceFilters       []InstanceFilter
+	random                *rand.Rand
+}
+
+func newRegistryCache(httpClient ContextHttpClient, clientProperties ClientProperties, logger Logger, metrics MetricsRecorder) *RegistryCache {
+	cache := &RegistryCache{
+		httpClient:         httpClient,
+		clientProperties:   clientProperties,
+		remoteApplications: make(map[string]*Applications),
+		metrics:            NoOpMetricsRecorder{},
+		logger:             NoOpLogger{},
+		reportedApps:       make(map[string]bool),
+		heldApps:           make(map[string]time.Time),
+		eventListeners:     make([]EventListener, 0),
+		propertyFilters:    newPropertyInstanceFilters(clientProperties),
+		instanceFilters:    make([]InstanceFilter, 0),
+		random:             newShuffleRandom(clientProperties.ShuffleSeed),
+	}
+
+	if clientProperties.BackupRegistryFile != "" {
+		cache.backupRegistry = NewFileBackupRegistry(clientProperties.BackupRegistryFile)
+	}
+	cache.SetLogger(logger)
+	cache.SetMetricsRecorder(metrics)
+	return cache
+}
+
+func (cache *RegistryCache) SetBackupRegistry(backupRegistry BackupRegistry) {
+	cache.fetchMu.Lock()
+	defer cache.fetchMu.Unlock()
+	cache.backupRegistry = backupRegistry
+	cache.backupDigest = ""
+}
+
+func (cache *RegistryCache) SetClientProperties(clientProperties ClientProperties) {
+	cache.fetchMu.Lock()
+
+	if clientProperties.ShuffleSeed != cache.clientProperties.ShuffleSeed {
+		cache.random = newShuffleRandom(clientProperties.ShuffleSeed)
+	}
+	cache.propertyFilters = newPropertyInstanceFilters(clientProperties)
+
+	if clientProperties.BackupRegistryFile != cache.clientProperties.BackupRegistryFile {
+		cache.backupRegistry = nil
+		cache.backupDigest = ""
+		if clientProperties.BackupRegistryFile != "" {
+			cache.backupRegistry = NewFileBackupRegistry(clientProperties.BackupRegistryFile)
+		}
+	}
+
+	cache.applicationsMu.Lock()
+	cache.clientProperties = clientProperties
+	cache.applicationsMu.Unlock()
+	cache.fetchMu.Unlock()
+
+	cache.lifecycleMu.Lock()
+	defer cache.lifecycleMu.Unlock()
+	if interval := cache.getFetchInterval(); cache.stopCh != nil && interval != cache.interval {
+		close(cache.stopCh)
+		cache.interval = interval
+		cache.stopCh = make(chan struct{})
+		go cache.run(cache.stopCh, interval)
+	}
+}
+
+func (cache *RegistryCache) AddEventListener(eventListener EventListener) {
+	cache.fetchMu.Lock()
+	defer cache.fetchMu.Unlock()
+	if eventListener != nil {
+		cache.eventListeners = append(cache.eventListeners, eventListener)
+	}
+}
+
+func (cache *RegistryCache) SetLogger(logger Logger) {
+	cache.fetchMu.Lock()
+	defer cache.fetchMu.Unlock()
+	cache.logger = wrapLogger(logger)
+}
+
+func (cache *RegistryCache) SetMetricsRecorder(metrics MetricsRecorder) {
+	cache.fetchMu.Lock()
+	defer cache.fetchMu.Unlock()
+	if metrics == nil {
+		metrics = NoOpMetricsRecorder{}
+	}
+	cache.metrics = metrics
+}
+
+func (cache *RegistryCache) Validate() error {
+	cache.applicationsMu.RLock()
+	clientProperties := cache.clientProperties
+	cache.applicationsMu.RUnlock()
+	return clientProperties.Validate()
+}
+
+// InitializePea is called once the properties are bound, the application does
+// not start with invalid properties.
+func (cache *RegistryCache) InitializePea() error {
+	return cache.Validate()
+}
+
+func (cache *RegistryCache) Start() error {
+	// the properties are read without waiting for a fetch in progress
+	if err := cache.Validate(); err != nil {
+		return err
+	}
+
+	err := cache.Refresh()
+
+	cache.lifecycleMu.Lock()
+	defer cache.lifecycleMu.Unlock()
+	if cache.stopCh != nil {
+		return err
+	}
+	cache.stopCh = make(chan struct{})
+	cache.interval = cache.getFetchInterval()
+	go cache.run(cache.stopCh, cache.interval)
+	return err
+}
+
+func (cache *RegistryCache) getFetchInterval() time.Duration {
+	cache.applicationsMu.RLock()
+	defer cache.applicationsMu.RUnlock()
+	interval := time.Duration(cache.clientProperties.RegistryFetchIntervalSeconds) * time.Second
+	if interval <= 0 {
+		interval = defaultRegistryFetchInterval
+	}
+	return interval
+}
+
+func (cache *RegistryCache) Stop() {
+	cache.lifecycleMu.Lock()
+	defer cache.lifecycleMu.Unlock()
+	if cache.stopCh != nil {
+		close(cache.stopCh)
+		cache.stopCh = nil
+	}
+}
+
+func (cache *RegistryCache) run(stopCh chan struct{}, interval time.Duration) {
+	cache.fetchMu.Lock()
+	task := newSupervisedTask(TaskCacheRefresh, cache.refresh, interval, cache.clientProperties.GetCacheRefreshTaskOptions(), cache.metrics, cache.logger)
+	cache.fetchMu.Unlock()
+	task.run(stopCh)
+}
+
+func (cache *RegistryCache) Refresh() error {
+	return cache.refresh(context.Background())
+}
+
+func (cache *RegistryCache) refresh(ctx context.Context) error {
+	cache.fetchMu.Lock()
+	defer cache.fetchMu.Unlock()
+
+	cache.applicationsMu.Lock()
+	if cache.firstAttemptTime.IsZero() {
+		cache.firstAttemptTime = time.Now()
+	}
+	cache.applicationsMu.Unlock()
+
+	fetchedApplications, err := cache.fetchLocalRegistry(ctx)
+	if err != nil {
+		cache.logger.Error("registry could not be fetched", Fields{}.withError(err))
+		cache.applicationsMu.Lock()
+		cache.consecutiveFailures++
+		cache.applicationsMu.Unlock()
+		cache.loadBackupRegistry()
+		return err
+	}
+	cache.fetchedApplications = fetchedApplications
+
+	// saving syncs the file to the disk, it is skipped as long as the registry stays the same.
+	// the hashcode only counts the statuses, a replaced instance is only seen in the content
+	if cache.backupRegistry != nil {
+		if digest := fetchedApplications.digest(); digest == "" || digest != cache.backupDigest {
+			if saveErr := cache.backupRegistry.Save(fetchedApplications); saveErr != nil {
+				cache.logger.Warning("registry backup could not be saved", Fields{}.withError(saveErr))
+			} else {
+				cache.backupDigest = digest
+			}
+		}
+	}
+
+	preservedApplications := cache.preserveInstances(cache.preservedApplications, fetchedApplications)
+	cache.preservedApplications = preservedApplications
+	applications := cache.buildView(preservedApplications)
+
+	localInstanceIds := make(map[string]bool)
+	for _, application := range applications.Applications {
+		for _, instance := range application.Instances {
+			localInstanceIds[instance.InstanceId] = true
+		}
+	}
+
+	var remoteErr error
+	remoteApplications := make(map[string]*Applications)
+	for _, region := range cache.clientProperties.GetRemoteRegions() {
+		regionApplications, err := cache.httpClient.GetApplicationsContext(ctx, region)
+		if err != nil {
+			remoteErr = err
+			cache.logger.Warning("remote region registry could not be fetched", Fields{FieldRegion: region}.withError(err))
+			// keep the last known view of the region
+			regionApplications = cache.GetApplicationsForRegion(region)
+		} else {
+			regionApplications = cache.buildView(cache.excludeInstances(regionApplications, localInstanceIds))
+		}
+		remoteApplications[region] = regionApplications
+	}
+
+	cache.applicationsMu.Lock()
+	cache.applications = applications
+	cache.remoteApplications = remoteApplications
+	cache.appsHashcode = fetchedApplications.AppsHashcode
+	cache.lastFetchTime = time.Now()
+	cache.consecutiveFailures = 0
+	cache.stale = false
+	cache.applicationsMu.Unlock()
+
+	cache.reportRegistrySize(applications)
+	cache.logger.Debug("registry fetched", Fields{
+		FieldInstances: applications.GetInstancesCount(),
+	})
+	return remoteErr
+}
+
+func (cache *RegistryCache) loadBackupRegistry() {
+	if cache.backupRegistry == nil || cache.GetApplications() != nil {
+		return
+	}
+
+	applications, err := cache.backupRegistry.Load()
+	if err != nil {
+		cache.logger.Warning("registry backup could not be loaded", Fields{}.withError(err))
+		return
+	}
+
+	// the backup is only a starting point, the next successful fetch replaces it
+	cache.preservedApplications = applications
+	cache.applicationsMu.Lock()
+	cache.applications = cache.buildView(applications)
+	cache.appsHashcode = applications.AppsHashcode
+	cache.stale = true
+	cache.applicationsMu.Unlock()
+
+	cache.logger.Info("registry loaded from the backup", Fields{
+		FieldInstances: applications.GetInstancesCount(),
+	})
+}
+
+func (cache *RegistryCache) fetchLocalRegistry(ctx context.Context) (*Applications, error) {
+	// the delta is always applied to what the server returned, not to the view served to the readers
+	current := cache.fetchedApplications
+	if current != nil && !cache.clientProperties.DisableDelta {
+		startTime := time.Now()
+		delta, err := cache.httpClient.GetApplicationsDeltaContext(ctx)
+		cache.reportFetch(FetchTypeDelta, startTime, err)
+
+		if err == nil && delta != nil {
+			cache.applicationsMu.Lock()
+			cache.lastDeltaVersion = delta.VersionsDelta
+			cache.applicationsMu.Unlock()
+
+			applications := current.copy()
+			applications.applyDelta(delta)
+			applications.AppsHashcode = applications.ComputeHashcode()
+			if applications.AppsHashcode == delta.AppsHashcode {
+				return applications, nil
+			}
+			cache.logger.Info("hash codes do not match after applying the delta, fetching the full registry", Fields{
+				FieldLocalHashcode:  applications.AppsHashcode,
+				FieldRemoteHashcode: delta.AppsHashcode,
+			})
+			cache.recordHashcodeMismatch(applications.AppsHashcode, delta.AppsHashcode)
+		}
+	}
+
+	startTime := time.Now()
+	applications, err := cache.httpClient.GetApplicationsContext(ctx)
+	cache.reportFetch(FetchTypeFull, startTime, err)
+
+	if err != nil {
+		return nil, err
+	}
+	if applications == nil {
+		applications = &Applications{}
+	}
+	return applications, nil
+}
+
+func (cache *RegistryCache) recordHashcodeMismatch(localHashcode, remoteHashcode string) {
+	cache.applicationsMu.Lock()
+	defer cache.applicationsMu.Unlock()
+	cache.hashcodeMismatches = append(cache.hashcodeMismatches, HashcodeMismatch{
+		Time:           time.Now(),
+		LocalHashcode:  localHashcode,
+		RemoteHashcode: remoteHashcode,
+	})
+	if len(cache.hashcodeMismatches) > maxHashcodeMismatches {
+		cache.hashcodeMismatches = cache.hashcodeMismatches[len(cache.hashcodeMismatches)-maxHashcodeMismatches:]
+	}
+}
+
+func (cache *RegistryCache) publishEvent(event Event) {
+	publishEvent(cache.eventListeners, event)
+}
+
+func (cache *RegistryCache) reportFetch(fetchType string, startTime time.Time, err error) {
+	result := ResultSuccess
+	if err != nil {
+		result = ResultFailure
+	}
+	labels := map[string]string{
+		LabelFetchType: fetchType,
+		LabelResult:    result,
+	}
+	cache.metrics.IncrementCounter(MetricRegistryFetches, labels)
+	cache.metrics.ObserveHistogram(MetricRegistryFetchDuration, time.Since(startTime).Seconds(), labels)
+}
+
+func (cache *RegistryCache) reportRegistrySize(applications *Applications) {
+	currentApps := make(map[string]bool)
+	for _, application := range applications.Applications {
+		currentApps[application.Name] = true
+		cache.metrics.SetGauge(MetricRegistryInstances, float64(len(application.Instances)), map[string]string{
+			LabelApp: application.Name,
+		})
+	}
+
+	// the apps which disappeared are reported as empty instead of keeping their last size
+	for appName := range cache.reportedApps {
+		if !currentApps[appName] {
+			cache.metrics.SetGauge(MetricRegistryInstances, 0, map[string]string{
+				LabelApp: appName,
+			})
+		}
+	}
+	cache.reportedApps = currentApps
+}
+
+func (cache *RegistryCache) excludeInstances(applications *Applications, instanceIds map[string]bool) *Applications {
+	filtered := &Applications{}
+	if applications == nil {
+		return filtered
+	}
+	filtered.VersionsDelta = applications.VersionsDelta
+	for _, application := range applications.Applications {
+		instances := make([]InstanceInfo, 0)
+		for _, instance := range application.Instances {
+			if !instanceIds[instance.InstanceId] {
+				instances = append(instances, instance)
+			}
+		}
+		if len(instances) != 0 {
+			filtered.Applications = append(filtered.Applications, Application{
+				Name:      application.Name,
+				Instances: instances,
+			})
+		}
+	}
+	filtered.AppsHashcode = filtered.ComputeHashcode()
+	return filtered
+}
+
+func (cache *RegistryCache) getClientProperties() ClientProperties {
+	cache.applicationsMu.RLock()
+	defer cache.applicationsMu.RUnlock()
+	return cache.clientProperties
+}
+
+func (cache *RegistryCache) GetApplications() *Applications {
+	cache.applicationsMu.RLock()
+	defer cache.applicationsMu.RUnlock()
+	return cache.applications
+}
+
+func (cache *RegistryCache) GetApplicationsForRegion(region string) *Applications {
+	cache.applicationsMu.RLock()
+	defer cache.applicationsMu.RUnlock()
+	return cache.remoteApplications[region]
+}
+
+func (cache *RegistryCache) GetRemoteRegions() []string {
+	cache.applicationsMu.RLock()
+	defer cache.applicationsMu.RUnlock()
+	return cache.clientProperties.GetRemoteRegions()
+}
+
+func (cache *RegistryCache) GetLastFetchTime() time.Time {
+	cache.applicationsMu.RLock()
+	defer cache.applicationsMu.RUnlock()
+	return cache.lastFetchTime
+}
+
+func (cache *RegistryCache) getUnreachableSince() time.Time {
+	cache.applicationsMu.RLock()
+	defer cache.applicationsMu.RUnlock()
+	// the server has been unreachable since the first attempt if no fetch ever succeeded
+	if cache.lastFetchTime.IsZero() {
+		return cache.firstAttemptTime
+	}
+	return cache.lastFetchTime
+}
+
+func (cache *RegistryCache) GetLastDeltaVersion() string {
+	cache.applicationsMu.RLock()
+	defer cache.applicationsMu.RUnlock()
+	return cache.lastDeltaVersion
+}
+
+// GetAppsHashcode returns the hashcode of the registry the server returned,
+// the filters and the preserved instances are not part of it.
+func (cache *RegistryCache) GetAppsHashcode() string {
+	cache.applicationsMu.RLock()
+	defer cache.applicationsMu.RUnlock()
+	return cache.appsHashcode
+}
+
+func (cache *RegistryCache) GetHashcodeMismatches() []HashcodeMismatch {
+	cache.applicationsMu.RLock()
+	defer cache.applicationsMu.RUnlock()
+	return append(make([]HashcodeMismatch, 0), cache.hashcodeMismatches...)
+}
+
+func (cache *RegistryCache) GetConsecutiveFailures() int {
+	cache.applicationsMu.RLock()
+	defer cache.applicationsMu.RUnlock()
+	return cache.consecutiveFailures
+}
+
+func (cache *RegistryCache) IsStale() bool {
+	cache.applicationsMu.RLock()
+	defer cache.applicationsMu.RUnlock()
+	return cache.stale
+}
@@ -2,6 +2,7 @@ package eureka
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"errors"
 	"io/ioutil"
@@ -9,6 +10,7 @@ import (
 	"net/url"
 	"strconv"
 	"strings"
+	"time"
 )
 
 type HttpClient interface {
@@ -20,27 +22,142 @@ type HttpClient interface {
 	GetInstanceByAppNameAndInstanceId(appName, instanceId string) (*InstanceInfo, error)
 	GetInstanceByInstanceId(instanceId string) (*InstanceInfo, error)
 	GetApplications(regions ...string) (*Applications, error)
+	GetApplicationsDelta(regions ...string) (*Applications, error)
+	GetVip(vipAddress string) (*Applications, error)
+	GetSecureVip(secureVipAddress string) (*Applications, error)
+	UpdateMetadata(appName, instanceId string, metadata map[string]string) error
+	DeleteStatusOverride(appName, instanceId string, info *InstanceInfo) error
 }
 
+// ContextHttpClient sends each request with the context of the call, which
+// carries its deadline, its cancellation and its trace parent.
+type ContextHttpClient interface {
+	HttpClient
+	RegisterContext(ctx context.Context, info *InstanceInfo) error
+	DeregisterContext(ctx context.Context, appName, instanceId string) error
+	SendHeartBeatContext(ctx context.Context, appName, instanceId string, info *InstanceInfo, overriddenStatus InstanceStatus) error
+	UpdateStatusContext(ctx context.Context, appName, instanceId string, newStatus InstanceStatus, info *InstanceInfo) error
+	GetApplicationContext(ctx context.Context, appName string) (*Application, error)
+	GetInstanceByAppNameAndInstanceIdContext(ctx context.Context, appName, instanceId string) (*InstanceInfo, error)
+	GetInstanceByInstanceIdContext(ctx context.Context, instanceId string) (*InstanceInfo, error)
+	GetApplicationsContext(ctx context.Context, regions ...string) (*Applications, error)
+	GetApplicationsDeltaContext(ctx context.Context, regions ...string) (*Applications, error)
+	GetVipContext(ctx context.Context, vipAddress string) (*Applications, error)
+	GetSecureVipContext(ctx context.Context, secureVipAddress string) (*Applications, error)
+	UpdateMetadataContext(ctx context.Context, appName, instanceId string, metadata map[string]string) error
+	DeleteStatusOverrideContext(ctx context.Context, appName, instanceId string, info *InstanceInfo) error
+}
+
+const (
+	OperationRegister             = "register"
+	OperationDeregister           = "deregister"
+	OperationHeartbeat            = "heartbeat"
+	OperationUpdateStatus         = "updateStatus"
+	OperationDeleteStatusOverride = "deleteStatusOverride"
+	OperationUpdateMetadata       = "updateMetadata"
+	OperationGetApplication       = "getApplication"
+	OperationGetInstance          = "getInstance"
+	OperationGetInstanceById      = "getInstanceById"
+	OperationGetApplications      = "getApplications"
+	OperationGetApplicationsDelta = "getApplicationsDelta"
+	OperationGetVip               = "getVip"
+	OperationGetSecureVip         = "getSecureVip"
+)
+
+type ResponseError struct {
+	StatusCode int
+	Message    string
+}
+
+func (responseError *ResponseError) Error() string {
+	return "eureka: " + strconv.Itoa(responseError.StatusCode) + " " + responseError.Message
+}
+
+// a request is given up when no response is read in time, even when the caller has no deadline
+const defaultRequestTimeout = 30 * time.Second
+
 type DefaultHttpClient struct {
-	client     *http.Client
-	serviceUrl string
+	client             *http.Client
+	serviceUrlProvider ServiceUrlProvider
+	metrics            MetricsRecorder
+	logger             Logger
+	tracer             Tracer
 }
 
-func newDefaultHttpClient(serviceUrl string) DefaultHttpClient {
+func NewDefaultHttpClient(serviceUrls ...string) DefaultHttpClient {
 	return DefaultHttpClient{
-		client:     http.DefaultClient,
-		serviceUrl: serviceUrl,
+		client: &http.Client{
+			Transport: newRefreshableTransport(),
+			Timeout:   defaultRequestTimeout,
+		},
+		serviceUrlProvider: StaticServiceUrlProvider(serviceUrls),
+		metrics:            NoOpMetricsRecorder{},
+		logger:             NoOpLogger{},
+		tracer:             NoOpTracer{},
+	}
+}
+
+func newHttpClient(clientProperties ClientProperties, instanceProperties InstanceProperties, logger Logger, metrics MetricsRecorder, tracer Tracer) DefaultHttpClient {
+	// the client is copied into every component, so everything it records with is set before
+	httpClient := NewDefaultHttpClient().WithLogger(logger).WithMetricsRecorder(metrics).WithTracer(tracer)
+
+	instanceZone := clientProperties.GetZone(&instanceProperties)
+	if clientProperties.UseDnsForFetchingServiceUrls {
+		dnsServiceUrlProvider := NewDnsServiceUrlProvider(clientProperties, instanceZone)
+		dnsServiceUrlProvider.SetLogger(logger)
+		return httpClient.WithServiceUrlProvider(dnsServiceUrlProvider)
+	}
+	// the service urls can be replaced later when the properties are refreshed
+	serviceUrlProvider := NewRefreshableServiceUrlProvider(clientProperties.GetEurekaServiceUrls(instanceZone)...)
+	return httpClient.WithServiceUrlProvider(serviceUrlProvider)
+}
+
+func (httpClient DefaultHttpClient) WithServiceUrlProvider(serviceUrlProvider ServiceUrlProvider) DefaultHttpClient {
+	if serviceUrlProvider == nil {
+		serviceUrlProvider = StaticServiceUrlProvider(nil)
+	}
+	httpClient.serviceUrlProvider = serviceUrlProvider
+	return httpClient
+}
+
+func (httpClient DefaultHttpClient) WithTracer(tracer Tracer) DefaultHttpClient {
+	if tracer == nil {
+		tracer = NoOpTracer{}
+	}
+	httpClient.tracer = tracer
+	return httpClient
+}
+
+func (httpClient DefaultHttpClient) WithLogger(logger Logger) DefaultHttpClient {
+	httpClient.logger = wrapLogger(logger)
+	return httpClient
+}
+
+func (httpClient DefaultHttpClient) WithMetricsRecorder(metrics MetricsRecorder) DefaultHttpClient {
+	if metrics == nil {
+		metrics = NoOpMetricsRecorder{}
+	}
+	httpClient.metrics = metrics
+	return httpClient
+}
+
+func (httpClient DefaultHttpClient) RebuildTransport() {
+	if transport, ok := httpClient.client.Transport.(*refreshableTransport); ok {
+		transport.Rebuild()
 	}
 }
 
 func (httpClient DefaultHttpClient) Register(info *InstanceInfo) error {
+	return httpClient.RegisterContext(context.Background(), info)
+}
+
+func (httpClient DefaultHttpClient) RegisterContext(ctx context.Context, info *InstanceInfo) error {
 	instanceResource := &InstanceResource{
 		InstanceInfo: info,
 	}
 
-	resp, err := httpClient.makeRequest(http.MethodPost,
-		httpClient.serviceUrl+"apps/"+info.AppName,
+	resp, err := httpClient.makeRequest(ctx, OperationRegister, http.MethodPost,
+		"apps/"+info.AppName,
 		instanceResource,
 		map[string]string{
 			"Accept-Encoding": "gzip",
@@ -51,6 +168,7 @@ func (httpClient DefaultHttpClient) Register(info *InstanceInfo) error {
 	if err != nil {
 		return err
 	}
+	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusNoContent {
 		return httpClient.getError(resp)
@@ -60,14 +178,19 @@ func (httpClient DefaultHttpClient) Register(info *InstanceInfo) error {
 }
 
 func (httpClient DefaultHttpClient) Deregister(appName, instanceId string) error {
-	resp, err := httpClient.makeRequest(http.MethodDelete,
-		httpClient.serviceUrl+"apps/"+appName+"/"+instanceId,
+	return httpClient.DeregisterContext(context.Background(), appName, instanceId)
+}
+
+func (httpClient DefaultHttpClient) DeregisterContext(ctx context.Context, appName, instanceId string) error {
+	resp, err := httpClient.makeRequest(ctx, OperationDeregister, http.MethodDelete,
+		"apps/"+appName+"/"+instanceId,
 		nil,
 		nil)
 
 	if err != nil {
 		return err
 	}
+	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
 		return httpClient.getError(resp)
@@ -77,7 +200,11 @@ func (httpClient DefaultHttpClient) Deregister(appName, instanceId string) error
 }
 
 func (httpClient DefaultHttpClient) SendHeartBeat(appName, instanceId string, info *InstanceInfo, overriddenStatus InstanceStatus) error {
-	heartBeatUrl, err := url.Parse(httpClient.serviceUrl + "apps/" + appName + "/" + instanceId)
+	return httpClient.SendHeartBeatContext(context.Background(), appName, instanceId, info, overriddenStatus)
+}
+
+func (httpClient DefaultHttpClient) SendHeartBeatContext(ctx context.Context, appName, instanceId string, info *InstanceInfo, overriddenStatus InstanceStatus) error {
+	heartBeatUrl, err := url.Parse("apps/" + appName + "/" + instanceId)
 
 	if err != nil {
 		return err
@@ -91,7 +218,7 @@ func (httpClient DefaultHttpClient) SendHeartBeat(appName, instanceId string, in
 	heartBeatUrl.RawQuery = query.Encode()
 
 	var resp *http.Response
-	resp, err = httpClient.makeRequest(http.MethodPut,
+	resp, err = httpClient.makeRequest(ctx, OperationHeartbeat, http.MethodPut,
 		heartBeatUrl.String(),
 		nil,
 		nil)
@@ -99,6 +226,7 @@ func (httpClient DefaultHttpClient) SendHeartBeat(appName, instanceId string, in
 	if err != nil {
 		return err
 	}
+	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
 		return httpClient.getError(resp)
@@ -108,7 +236,11 @@ func (httpClient DefaultHttpClient) SendHeartBeat(appName, instanceId string, in
 }
 
 func (httpClient DefaultHttpClient) UpdateStatus(appName, instanceId string, newStatus InstanceStatus, info *InstanceInfo) error {
-	updateStatusUrl, err := url.Parse(httpClient.serviceUrl + "apps/" + appName + "/" + instanceId + "/status")
+	return httpClient.UpdateStatusContext(context.Background(), appName, instanceId, newStatus, info)
+}
+
+func (httpClient DefaultHttpClient) UpdateStatusContext(ctx context.Context, appName, instanceId string, newStatus InstanceStatus, info *InstanceInfo) error {
+	updateStatusUrl, err := url.Parse("apps/" + appName + "/" + instanceId + "/status")
 
 	if err != nil {
 		return err
@@ -122,7 +254,7 @@ func (httpClient DefaultHttpClient) UpdateStatus(appName, instanceId string, new
 	updateStatusUrl.RawQuery = query.Encode()
 
 	var resp *http.Response
-	resp, err = httpClient.makeRequest(http.MethodPut,
+	resp, err = httpClient.makeRequest(ctx, OperationUpdateStatus, http.MethodPut,
 		updateStatusUrl.String(),
 		nil,
 		nil)
@@ -130,6 +262,7 @@ func (httpClient DefaultHttpClient) UpdateStatus(appName, instanceId string, new
 	if err != nil {
 		return err
 	}
+	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
 		return httpClient.getError(resp)
@@ -139,8 +272,12 @@ func (httpClient DefaultHttpClient) UpdateStatus(appName, instanceId string, new
 }
 
 func (httpClient DefaultHttpClient) GetApplication(appName string) (*Application, error) {
-	resp, err := httpClient.makeRequest(http.MethodGet,
-		httpClient.serviceUrl+"apps/"+appName,
+	return httpClient.GetApplicationContext(context.Background(), appName)
+}
+
+func (httpClient DefaultHttpClient) GetApplicationContext(ctx context.Context, appName string) (*Application, error) {
+	resp, err := httpClient.makeRequest(ctx, OperationGetApplication, http.MethodGet,
+		"apps/"+appName,
 		nil,
 		map[string]string{
 			"Accept": "application/json",
@@ -150,6 +287,7 @@ func (httpClient DefaultHttpClient) GetApplication(appName string) (*Application
 	if err != nil {
 		return nil, err
 	}
+	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
 		return nil, httpClient.getError(resp)
@@ -166,8 +304,12 @@ func (httpClient DefaultHttpClient) GetApplication(appName string) (*Application
 }
 
 func (httpClient DefaultHttpClient) GetInstanceByAppNameAndInstanceId(appName, instanceId string) (*InstanceInfo, error) {
-	resp, err := httpClient.makeRequest(http.MethodGet,
-		httpClient.serviceUrl+"apps/"+appName+"/"+instanceId,
+	return httpClient.GetInstanceByAppNameAndInstanceIdContext(context.Background(), appName, instanceId)
+}
+
+func (httpClient DefaultHttpClient) GetInstanceByAppNameAndInstanceIdContext(ctx context.Context, appName, instanceId string) (*InstanceInfo, error) {
+	resp, err := httpClient.makeRequest(ctx, OperationGetInstance, http.MethodGet,
+		"apps/"+appName+"/"+instanceId,
 		nil,
 		map[string]string{
 			"Accept": "application/json",
@@ -177,6 +319,7 @@ func (httpClient DefaultHttpClient) GetInstanceByAppNameAndInstanceId(appName, i
 	if err != nil {
 		return nil, err
 	}
+	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
 		return nil, httpClient.getError(resp)
@@ -193,8 +336,12 @@ func (httpClient DefaultHttpClient) GetInstanceByAppNameAndInstanceId(appName, i
 }
 
 func (httpClient DefaultHttpClient) GetInstanceByInstanceId(instanceId string) (*InstanceInfo, error) {
-	resp, err := httpClient.makeRequest(http.MethodGet,
-		httpClient.serviceUrl+"instances/"+instanceId,
+	return httpClient.GetInstanceByInstanceIdContext(context.Background(), instanceId)
+}
+
+func (httpClient DefaultHttpClient) GetInstanceByInstanceIdContext(ctx context.Context, instanceId string) (*InstanceInfo, error) {
+	resp, err := httpClient.makeRequest(ctx, OperationGetInstanceById, http.MethodGet,
+		"instances/"+instanceId,
 		nil,
 		map[string]string{
 			"Accept": "application/json",
@@ -204,6 +351,7 @@ func (httpClient DefaultHttpClient) GetInstanceByInstanceId(instanceId string) (
 	if err != nil {
 		return nil, err
 	}
+	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
 		return nil, httpClient.getError(resp)
@@ -218,27 +366,123 @@ func (httpClient DefaultHttpClient) GetInstanceByInstanceId(instanceId string) (
 }
 
 func (httpClient DefaultHttpClient) GetApplications(regions ...string) (*Applications, error) {
-	applicationsUrl, err := url.Parse(httpClient.serviceUrl + "apps")
+	return httpClient.GetApplicationsContext(context.Background(), regions...)
+}
+
+func (httpClient DefaultHttpClient) GetApplicationsContext(ctx context.Context, regions ...string) (*Applications, error) {
+	return httpClient.getApplications(ctx, OperationGetApplications, "apps", regions)
+}
+
+func (httpClient DefaultHttpClient) GetApplicationsDelta(regions ...string) (*Applications, error) {
+	return httpClient.GetApplicationsDeltaContext(context.Background(), regions...)
+}
+
+func (httpClient DefaultHttpClient) GetApplicationsDeltaContext(ctx context.Context, regions ...string) (*Applications, error) {
+	return httpClient.getApplications(ctx, OperationGetApplicationsDelta, "apps/delta", regions)
+}
+
+func (httpClient DefaultHttpClient) GetVip(vipAddress string) (*Applications, error) {
+	return httpClient.GetVipContext(context.Background(), vipAddress)
+}
+
+func (httpClient DefaultHttpClient) GetVipContext(ctx context.Context, vipAddress string) (*Applications, error) {
+	return httpClient.getApplications(ctx, OperationGetVip, "vips/"+vipAddress, nil)
+}
+
+func (httpClient DefaultHttpClient) GetSecureVip(secureVipAddress string) (*Applications, error) {
+	return httpClient.GetSecureVipContext(context.Background(), secureVipAddress)
+}
+
+func (httpClient DefaultHttpClient) GetSecureVipContext(ctx context.Context, secureVipAddress string) (*Applications, error) {
+	return httpClient.getApplications(ctx, OperationGetSecureVip, "svips/"+secureVipAddress, nil)
+}
+
+func (httpClient DefaultHttpClient) UpdateMetadata(appName, instanceId string, metadata map[string]string) error {
+	return httpClient.UpdateMetadataContext(context.Background(), appName, instanceId, metadata)
+}
+
+func (httpClient DefaultHttpClient) UpdateMetadataContext(ctx context.Context, appName, instanceId string, metadata map[string]string) error {
+	metadataUrl, err := url.Parse("apps/" + appName + "/" + instanceId + "/metadata")
 
 	if err != nil {
-		return nil, err
+		return err
+	}
+
+	query := metadataUrl.Query()
+
+	for key, value := range metadata {
+		query.Add(key, value)
+	}
+
+	metadataUrl.RawQuery = query.Encode()
+
+	var resp *http.Response
+	resp, err = httpClient.makeRequest(ctx, OperationUpdateMetadata, http.MethodPut,
+		metadataUrl.String(),
+		nil,
+		nil)
+
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return httpClient.getError(resp)
+	}
+
+	return nil
+}
+
+func (httpClient DefaultHttpClient) DeleteStatusOverride(appName, instanceId string, info *InstanceInfo) error {
+	return httpClient.DeleteStatusOverrideContext(context.Background(), appName, instanceId, info)
+}
+
+func (httpClient DefaultHttpClient) DeleteStatusOverrideContext(ctx context.Context, appName, instanceId string, info *InstanceInfo) error {
+	statusUrl, err := url.Parse("apps/" + appName + "/" + instanceId + "/status")
+
+	if err != nil {
+		return err
 	}
 
-	query := applicationsUrl.Query()
+	query := statusUrl.Query()
+	query.Add("lastDirtyTimestamp", info.LastDirtyTimestamp)
+
+	statusUrl.RawQuery = query.Encode()
+
+	var resp *http.Response
+	resp, err = httpClient.makeRequest(ctx, OperationDeleteStatusOverride, http.MethodDelete,
+		statusUrl.String(),
+		nil,
+		nil)
+
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return httpClient.getError(resp)
+	}
+
+	return nil
+}
 
-	regionParameter := ""
+func (httpClient DefaultHttpClient) getApplications(ctx context.Context, operation string, applicationsPath string, regions []string) (*Applications, error) {
+	applicationsUrl, err := url.Parse(applicationsPath)
 
-	for _, region := range regions {
-		regionParameter = regionParameter + "," + region
+	if err != nil {
+		return nil, err
 	}
 
-	if regionParameter != "" {
-		query.Add("regions", regionParameter[1:])
+	if len(regions) != 0 {
+		query := applicationsUrl.Query()
+		query.Add("regions", strings.Join(regions, ","))
 		applicationsUrl.RawQuery = query.Encode()
 	}
 
 	var resp *http.Response
-	resp, err = httpClient.makeRequest(http.MethodGet,
+	resp, err = httpClient.makeRequest(ctx, operation, http.MethodGet,
 		applicationsUrl.String(),
 		nil,
 		map[string]string{
@@ -249,9 +493,10 @@ func (httpClient DefaultHttpClient) GetApplications(regions ...string) (*Applica
 	if err != nil {
 		return nil, err
 	}
+	defer resp.Body.Close()
 
-	if strings.Trim(resp.Status, " ") != strconv.Itoa(http.StatusOK) {
-		return nil, nil
+	if resp.StatusCode != http.StatusOK {
+		return nil, httpClient.getError(resp)
 	}
 
 	applicationsResource := &ApplicationsResource{}
@@ -263,35 +508,125 @@ func (httpClient DefaultHttpClient) GetApplications(regions ...string) (*Applica
 }
 
 func (httpClient DefaultHttpClient) getError(resp *http.Response) error {
-	errorResponse := &Error{}
+	responseError := &ResponseError{
+		StatusCode: resp.StatusCode,
+		Message:    http.StatusText(resp.StatusCode),
+	}
 
+	errorResponse := &Error{}
 	err := httpClient.bindResponse(resp, errorResponse)
-	if err != nil {
-		return err
+	if err == nil && errorResponse.Message != "" {
+		responseError.Message = errorResponse.Message
 	}
 
-	return errors.New(errorResponse.Message)
+	return responseError
 }
 
-func (httpClient DefaultHttpClient) makeRequest(method string, url string, requestBodyObj interface{}, header map[string]string) (*http.Response, error) {
-	body, err := json.Marshal(requestBodyObj)
+func (httpClient DefaultHttpClient) makeRequest(ctx context.Context, operation string, method string, requestPath string, requestBodyObj interface{}, header map[string]string) (resp *http.Response, err error) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	startTime := time.Now()
+	ctx, span := httpClient.tracer.StartSpan(ctx, "eureka "+operation)
+	span.SetAttribute(AttributeEurekaOperation, operation)
+	span.SetAttribute(AttributeEurekaEndpoint, httpClient.getEndpoint(requestPath))
+	span.SetAttribute(AttributeHttpMethod, method)
+	if appName := httpClient.getAppName(requestPath); appName != "" {
+		span.SetAttribute(AttributeEurekaApp, appName)
+	}
+
+	defer func() {
+		status := "error"
+		if resp != nil {
+			status = strconv.Itoa(resp.StatusCode)
+			span.SetAttribute(AttributeHttpStatusCode, resp.StatusCode)
+		}
+		span.RecordError(err)
+		span.End()
+
+		labels := map[string]string{
+			LabelOperation: operation,
+			LabelStatus:    status,
+		}
+		httpClient.metrics.IncrementCounter(MetricRequests, labels)
+		httpClient.metrics.ObserveHistogram(MetricRequestDuration, time.Since(startTime).Seconds(), labels)
+	}()
+
+	var body []byte
+	body, err = json.Marshal(requestBodyObj)
 
 	if err != nil {
 		return nil, err
 	}
 
-	var req *http.Request
-	req, err = http.NewRequest(method, url, bytes.NewReader(body))
-	if err != nil {
-		return nil, err
+	serviceUrls := httpClient.serviceUrlProvider.GetServiceUrls()
+	if len(serviceUrls) == 0 {
+		return nil, errors.New("eureka: there is no service url to send the request")
 	}
 
-	if header != nil {
-		for headerKey, headerValue := range header {
-			req.Header.Set(headerKey, headerValue)
+	// the service urls are tried in order, the next one is used
+	// only if the current one is unreachable or fails with 5xx
+	for index, serviceUrl := range serviceUrls {
+		var req *http.Request
+		req, err = http.NewRequestWithContext(ctx, method, serviceUrl+requestPath, bytes.NewReader(body))
+		if err != nil {
+			return nil, err
 		}
+
+		if traceParent := span.TraceParent(); traceParent != "" {
+			req.Header.Set(TraceParentHeader, traceParent)
+		}
+		span.SetAttribute(AttributeHttpUrl, req.URL.String())
+
+		if header != nil {
+			for headerKey, headerValue := range header {
+				req.Header.Set(headerKey, headerValue)
+			}
+		}
+
+		resp, err = httpClient.client.Do(req)
+
+		fields := Fields{
+			FieldOperation:  operation,
+			FieldServiceUrl: serviceUrl,
+			FieldAttempt:    index + 1,
+		}
+
+		if err == nil && resp.StatusCode < http.StatusInternalServerError {
+			httpClient.logger.Debug("eureka request completed", fields.with(FieldStatusCode, resp.StatusCode))
+			return resp, nil
+		}
+
+		if err != nil {
+			httpClient.logger.Warning("eureka request failed", fields.withError(err))
+			// the caller gave up, the other servers would not be waited for either
+			if ctx.Err() != nil {
+				return nil, err
+			}
+		} else {
+			httpClient.logger.Warning("eureka request failed", fields.with(FieldStatusCode, resp.StatusCode))
+			if index == len(serviceUrls)-1 {
+				return resp, nil
+			}
+			resp.Body.Close()
+		}
+	}
+	return nil, err
+}
+
+func (httpClient DefaultHttpClient) getEndpoint(requestPath string) string {
+	if index := strings.Index(requestPath, "?"); index != -1 {
+		return requestPath[:index]
+	}
+	return requestPath
+}
+
+func (httpClient DefaultHttpClient) getAppName(requestPath string) string {
+	parts := strings.Split(httpClient.getEndpoint(requestPath), "/")
+	if len(parts) > 1 && parts[0] == "apps" && parts[1] != "delta" {
+		return parts[1]
 	}
-	return httpClient.client.Do(req)
+	return ""
 }
 
 func (httpClient DefaultHttpClient) bindResponse(resp *http.Response, responseObject interface{}) error {
@@ -0,0 +1,106 @@
+package eureka
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+)
+
+// fakeEurekaServer serves the registry of the tests, the eurekatest server
+// cannot be used from the tests of this package.
+type fakeEurekaServer struct {
+	*httptest.Server
+	applications       *Applications
+	remoteApplications map[string]*Applications
+	statusCode         int
+	requests           []string
+	registrations      []InstanceInfo
+	serverMu           sync.Mutex
+}
+
+func newFakeEurekaServer(applications *Applications) *fakeEurekaServer {
+	server := &fakeEurekaServer{
+		applications:       applications,
+		remoteApplications: make(map[string]*Applications),
+	}
+	server.Server = httptest.NewServer(http.HandlerFunc(server.serve))
+	return server
+}
+
+func (server *fakeEurekaServer) serviceUrl() string {
+	return server.URL + "/eureka/"
+}
+
+func (server *fakeEurekaServer) setApplications(applications *Applications) {
+	server.serverMu.Lock()
+	defer server.serverMu.Unlock()
+	server.applications = applications
+}
+
+func (server *fakeEurekaServer) setRemoteApplications(region string, applications *Applications) {
+	server.serverMu.Lock()
+	defer server.serverMu.Unlock()
+	server.remoteApplications[region] = applications
+}
+
+// setStatusCode makes every request fail with the given status code, 0 serves them again.
+func (server *fakeEurekaServer) setStatusCode(statusCode int) {
+	server.serverMu.Lock()
+	defer server.serverMu.Unlock()
+	server.statusCode = statusCode
+}
+
+func (server *fakeEurekaServer) getRequests() []string {
+	server.serverMu.Lock()
+	defer server.serverMu.Unlock()
+	return append([]string(nil), server.requests...)
+}
+
+func (server *fakeEurekaServer) getRegistrations() []InstanceInfo {
+	server.serverMu.Lock()
+	defer server.serverMu.Unlock()
+	return append([]InstanceInfo(nil), server.registrations...)
+}
+
+func (server *fakeEurekaServer) serve(writer http.ResponseWriter, request *http.Request) {
+	server.serverMu.Lock()
+	defer server.serverMu.Unlock()
+
+	path := strings.TrimPrefix(request.URL.Path, "/eureka/")
+	server.requests = append(server.requests, request.Method+" "+path)
+	if server.statusCode != 0 {
+		writer.WriteHeader(server.statusCode)
+		return
+	}
+
+	switch {
+	case request.Method == http.MethodGet && (path == "apps" || path == "apps/delta"):
+		applications := server.applications
+		if region := request.URL.Query().Get("regions"); region != "" {
+			applications = server.remoteApplications[region]
+		}
+		if applications == nil {
+			applications = &Applications{}
+		}
+		applications = applications.copy()
+		applications.AppsHashcode = applications.ComputeHashcode()
+		// the delta carries no change, the client keeps what it has
+		if path == "apps/delta" {
+			applications.Applications = nil
+		}
+		writer.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(writer).Encode(&ApplicationsResource{Applications: applications})
+	case request.Method == http.MethodPost:
+		instanceResource := &InstanceResource{}
+		if err := json.NewDecoder(request.Body).Decode(instanceResource); err != nil || instanceResource.InstanceInfo == nil {
+			writer.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		server.registrations = append(server.registrations, *instanceResource.InstanceInfo)
+		writer.WriteHeader(http.StatusNoContent)
+	default:
+		writer.WriteHeader(http.StatusOK)
+	}
+}
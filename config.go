@@ -5,6 +5,8 @@ import (
 	"net"
 	"os"
 	"strconv"
+	"strings"
+	"time"
 )
 
 const (
@@ -12,6 +14,9 @@ const (
 	DefaultPrefix = "/eureka"
 	DefaultUrl    = "http://localhost:8761" + DefaultPrefix + "/"
 	DefaultZone   = "defaultZone"
+	DefaultRegion = "us-east-1"
+	ZoneKey       = "zone"
+	FallbackKey   = "fallback"
 
 	securePort    = 443
 	nonSecurePort = 80
@@ -23,35 +28,206 @@ const (
 )
 
 type ClientProperties struct {
-	RegistryWithEureka bool `json:"registryWithEureka,omitempty" yaml:"registryWithEureka,omitempty"`
-	FetchRegistry      bool `json:"fetchRegistry,omitempty" yaml:"fetchRegistry,omitempty"`
+	RegistryWithEureka                     bool                                    `json:"registryWithEureka,omitempty" yaml:"registryWithEureka,omitempty"`
+	FetchRegistry                          bool                                    `json:"fetchRegistry,omitempty" yaml:"fetchRegistry,omitempty"`
+	RegistryFetchIntervalSeconds           int                                     `json:"registryFetchIntervalSeconds,omitempty" yaml:"registryFetchIntervalSeconds,omitempty"`
+	FetchRemoteRegionsRegistry             string                                  `json:"fetchRemoteRegionsRegistry,omitempty" yaml:"fetchRemoteRegionsRegistry,omitempty"`
+	Region                                 string                                  `json:"region,omitempty" yaml:"region,omitempty"`
+	AvailabilityZones                      map[string]string                       `json:"availabilityZones,omitempty" yaml:"availabilityZones,omitempty"`
+	ServiceUrl                             map[string]string                       `json:"serviceUrl,omitempty" yaml:"serviceUrl,omitempty"`
+	PreferSameZoneEureka                   bool                                    `json:"preferSameZoneEureka,omitempty" yaml:"preferSameZoneEureka,omitempty"`
+	BackupRegistryFile                     string                                  `json:"backupRegistryFile,omitempty" yaml:"backupRegistryFile,omitempty"`
+	FallbackInstances                      map[string][]FallbackInstanceProperties `json:"fallbackInstances,omitempty" yaml:"fallbackInstances,omitempty"`
+	FallbackThresholdSeconds               int                                     `json:"fallbackThresholdSeconds,omitempty" yaml:"fallbackThresholdSeconds,omitempty"`
+	SelfPreservationThresholdPercent       int                                     `json:"selfPreservationThresholdPercent,omitempty" yaml:"selfPreservationThresholdPercent,omitempty"`
+	SelfPreservationGracePeriodSeconds     int                                     `json:"selfPreservationGracePeriodSeconds,omitempty" yaml:"selfPreservationGracePeriodSeconds,omitempty"`
+	FilterOnlyUpInstances                  bool                                    `json:"filterOnlyUpInstances,omitempty" yaml:"filterOnlyUpInstances,omitempty"`
+	ShuffleInstances                       bool                                    `json:"shuffleInstances,omitempty" yaml:"shuffleInstances,omitempty"`
+	ShuffleSeed                            int64                                   `json:"shuffleSeed,omitempty" yaml:"shuffleSeed,omitempty"`
+	DisableDelta                           bool                                    `json:"disableDelta,omitempty" yaml:"disableDelta,omitempty"`
+	IncludeMetadata                        map[string]string                       `json:"includeMetadata,omitempty" yaml:"includeMetadata,omitempty"`
+	ExcludeMetadata                        map[string]string                       `json:"excludeMetadata,omitempty" yaml:"excludeMetadata,omitempty"`
+	UseDnsForFetchingServiceUrls           bool                                    `json:"useDnsForFetchingServiceUrls,omitempty" yaml:"useDnsForFetchingServiceUrls,omitempty"`
+	EurekaServerDnsName                    string                                  `json:"eurekaServerDnsName,omitempty" yaml:"eurekaServerDnsName,omitempty"`
+	EurekaServerPort                       int                                     `json:"eurekaServerPort,omitempty" yaml:"eurekaServerPort,omitempty"`
+	EurekaServerUrlContext                 string                                  `json:"eurekaServerUrlContext,omitempty" yaml:"eurekaServerUrlContext,omitempty"`
+	EurekaServiceUrlPollIntervalSeconds    int                                     `json:"eurekaServiceUrlPollIntervalSeconds,omitempty" yaml:"eurekaServiceUrlPollIntervalSeconds,omitempty"`
+	InstanceInfoReplicationIntervalSeconds int                                     `json:"instanceInfoReplicationIntervalSeconds,omitempty" yaml:"instanceInfoReplicationIntervalSeconds,omitempty"`
+	OnDemandUpdateStatusChange             bool                                    `json:"onDemandUpdateStatusChange,omitempty" yaml:"onDemandUpdateStatusChange,omitempty"`
+	OnDemandUpdateRatePerMinute            int                                     `json:"onDemandUpdateRatePerMinute,omitempty" yaml:"onDemandUpdateRatePerMinute,omitempty"`
+	OnDemandUpdateBurstSize                int                                     `json:"onDemandUpdateBurstSize,omitempty" yaml:"onDemandUpdateBurstSize,omitempty"`
+	HeartbeatTimeoutSeconds                int                                     `json:"heartbeatTimeoutSeconds,omitempty" yaml:"heartbeatTimeoutSeconds,omitempty"`
+	HeartbeatMaxBackoffMultiplier          int                                     `json:"heartbeatMaxBackoffMultiplier,omitempty" yaml:"heartbeatMaxBackoffMultiplier,omitempty"`
+	HeartbeatExecutorPoolSize              int                                     `json:"heartbeatExecutorPoolSize,omitempty" yaml:"heartbeatExecutorPoolSize,omitempty"`
+	CacheRefreshTimeoutSeconds             int                                     `json:"cacheRefreshTimeoutSeconds,omitempty" yaml:"cacheRefreshTimeoutSeconds,omitempty"`
+	CacheRefreshMaxBackoffMultiplier       int                                     `json:"cacheRefreshMaxBackoffMultiplier,omitempty" yaml:"cacheRefreshMaxBackoffMultiplier,omitempty"`
+	CacheRefreshExecutorPoolSize           int                                     `json:"cacheRefreshExecutorPoolSize,omitempty" yaml:"cacheRefreshExecutorPoolSize,omitempty"`
+}
+
+type FallbackInstanceProperties struct {
+	Host     string            `json:"host,omitempty" yaml:"host,omitempty"`
+	Port     int               `json:"port,omitempty" yaml:"port,omitempty"`
+	Secure   bool              `json:"secure,omitempty" yaml:"secure,omitempty"`
+	Metadata map[string]string `json:"metadata,omitempty" yaml:"metadata,omitempty"`
 }
 
 func newClientProperties() *ClientProperties {
 	return &ClientProperties{
-		RegistryWithEureka: true,
-		FetchRegistry:      true,
+		RegistryWithEureka:           true,
+		FetchRegistry:                true,
+		RegistryFetchIntervalSeconds: 30,
+		Region:                       DefaultRegion,
+		AvailabilityZones:            make(map[string]string),
+		ServiceUrl: map[string]string{
+			DefaultZone: DefaultUrl,
+		},
+		PreferSameZoneEureka:                   true,
+		FallbackInstances:                      make(map[string][]FallbackInstanceProperties),
+		FallbackThresholdSeconds:               300,
+		SelfPreservationGracePeriodSeconds:     300,
+		FilterOnlyUpInstances:                  true,
+		ShuffleInstances:                       true,
+		IncludeMetadata:                        make(map[string]string),
+		ExcludeMetadata:                        make(map[string]string),
+		EurekaServerPort:                       8761,
+		EurekaServerUrlContext:                 strings.TrimPrefix(DefaultPrefix, "/"),
+		EurekaServiceUrlPollIntervalSeconds:    300,
+		InstanceInfoReplicationIntervalSeconds: 30,
+		OnDemandUpdateStatusChange:             true,
+		OnDemandUpdateRatePerMinute:            4,
+		OnDemandUpdateBurstSize:                2,
+		HeartbeatMaxBackoffMultiplier:          defaultMaxBackoffMultiplier,
+		HeartbeatExecutorPoolSize:              defaultTaskPoolSize,
+		CacheRefreshMaxBackoffMultiplier:       defaultMaxBackoffMultiplier,
+		CacheRefreshExecutorPoolSize:           defaultTaskPoolSize,
+	}
+}
+
+func (clientConfiguration *ClientProperties) GetRemoteRegions() []string {
+	return clientConfiguration.splitValues(clientConfiguration.FetchRemoteRegionsRegistry)
+}
+
+func (clientConfiguration *ClientProperties) GetFallbackInstances(serviceId string) []FallbackInstanceProperties {
+	for name, instances := range clientConfiguration.FallbackInstances {
+		if strings.EqualFold(name, serviceId) {
+			return instances
+		}
+	}
+	return nil
+}
+
+func (clientConfiguration *ClientProperties) GetHeartbeatTaskOptions() TaskOptions {
+	return TaskOptions{
+		Timeout:              time.Duration(clientConfiguration.HeartbeatTimeoutSeconds) * time.Second,
+		MaxBackoffMultiplier: clientConfiguration.HeartbeatMaxBackoffMultiplier,
+		PoolSize:             clientConfiguration.HeartbeatExecutorPoolSize,
+	}
+}
+
+func (clientConfiguration *ClientProperties) GetCacheRefreshTaskOptions() TaskOptions {
+	return TaskOptions{
+		Timeout:              time.Duration(clientConfiguration.CacheRefreshTimeoutSeconds) * time.Second,
+		MaxBackoffMultiplier: clientConfiguration.CacheRefreshMaxBackoffMultiplier,
+		PoolSize:             clientConfiguration.CacheRefreshExecutorPoolSize,
+	}
+}
+
+func (clientConfiguration *ClientProperties) GetAvailabilityZones(region string) []string {
+	zones := clientConfiguration.splitValues(clientConfiguration.AvailabilityZones[region])
+	if len(zones) == 0 {
+		zones = append(zones, DefaultZone)
+	}
+	return zones
+}
+
+func (clientConfiguration *ClientProperties) GetZone(instanceProperties *InstanceProperties) string {
+	if zone, ok := instanceProperties.MetadataMap[ZoneKey]; ok && zone != "" {
+		return zone
+	}
+	return clientConfiguration.GetAvailabilityZones(clientConfiguration.Region)[0]
+}
+
+func (clientConfiguration *ClientProperties) GetEurekaServiceUrls(instanceZone string) []string {
+	zones := clientConfiguration.GetAvailabilityZones(clientConfiguration.Region)
+
+	// the urls of the instance's own zone come first, the rest follow in the configured order
+	startIndex := 0
+	if clientConfiguration.PreferSameZoneEureka {
+		for index, zone := range zones {
+			if zone == instanceZone {
+				startIndex = index
+				break
+			}
+		}
+	}
+
+	serviceUrls := make([]string, 0)
+	for offset := 0; offset < len(zones); offset++ {
+		zone := zones[(startIndex+offset)%len(zones)]
+		serviceUrls = append(serviceUrls, clientConfiguration.getServiceUrls(zone)...)
+	}
+
+	if len(serviceUrls) == 0 {
+		serviceUrls = clientConfiguration.getServiceUrls(DefaultZone)
+	}
+
+	if len(serviceUrls) == 0 {
+		serviceUrls = append(serviceUrls, DefaultUrl)
 	}
+	return serviceUrls
+}
+
+func (clientConfiguration *ClientProperties) getServiceUrls(zone string) []string {
+	serviceUrls := clientConfiguration.splitValues(clientConfiguration.ServiceUrl[zone])
+	for index, serviceUrl := range serviceUrls {
+		if !strings.HasSuffix(serviceUrl, "/") {
+			serviceUrls[index] = serviceUrl + "/"
+		}
+	}
+	return serviceUrls
+}
+
+func (clientConfiguration *ClientProperties) splitValues(value string) []string {
+	values := make([]string, 0)
+	for _, part := range strings.Split(value, ",") {
+		part = strings.TrimSpace(part)
+		if part != "" {
+			values = append(values, part)
+		}
+	}
+	return values
 }
 
 func (clientConfiguration *ClientProperties) GetConfigurationPrefix() string {
 	return "procyon.cloud.eureka.client"
 }
 
+// InstanceProperties.InstanceId is left empty unless it is configured, the
+// instance id is generated by the instance id strategy when the instance info
+// is built.
 type InstanceProperties struct {
-	ApplicationName      string         `json:"appName,omitempty" yaml:"appName,omitempty"`
-	ApplicationGroupName string         `json:"appGroupName,omitempty" yaml:"appGroupName,omitempty"`
-	IpAddr               string         `json:"ipAddr,omitempty" yaml:"ipAddr,omitempty"`
-	DataCenterInfo       DataCenterInfo `json:"dataCenterInfo,omitempty" yaml:"dataCenterInfo,omitempty"`
-	SecurePort           int            `json:"securePort,omitempty" yaml:"securePort,omitempty"`
-	NonSecurePort        int            `json:"nonSecurePort,omitempty" yaml:"nonSecurePort,omitempty"`
-	NonSecurePortEnabled bool           `json:"nonSecurePortEnabled,omitempty" yaml:"nonSecurePortEnabled,omitempty"`
-	SecurePortEnabled    bool           `json:"securePortEnabled,omitempty" yaml:"securePortEnabled,omitempty"`
-	InstanceId           string         `json:"instanceId,omitempty" yaml:"instanceId,omitempty"`
-	StatusPageUrl        string         `json:"statusPageUrl,omitempty" yaml:"statusPageUrl,omitempty"`
-	HomePageUrl          string         `json:"homePageUrl,omitempty" yaml:"homePageUrl,omitempty"`
-	HealthCheckUrl       string         `json:"healthCheckUrl,omitempty" yaml:"healthCheckUrl,omitempty"`
-	Hostname             string         `json:"hostname,omitempty" yaml:"hostname,omitempty"`
+	ApplicationName                  string            `json:"appName,omitempty" yaml:"appName,omitempty"`
+	ApplicationGroupName             string            `json:"appGroupName,omitempty" yaml:"appGroupName,omitempty"`
+	IpAddr                           string            `json:"ipAddr,omitempty" yaml:"ipAddr,omitempty"`
+	DataCenterInfo                   DataCenterInfo    `json:"dataCenterInfo,omitempty" yaml:"dataCenterInfo,omitempty"`
+	SecurePort                       int               `json:"securePort,omitempty" yaml:"securePort,omitempty"`
+	NonSecurePort                    int               `json:"nonSecurePort,omitempty" yaml:"nonSecurePort,omitempty"`
+	NonSecurePortEnabled             bool              `json:"nonSecurePortEnabled,omitempty" yaml:"nonSecurePortEnabled,omitempty"`
+	SecurePortEnabled                bool              `json:"securePortEnabled,omitempty" yaml:"securePortEnabled,omitempty"`
+	InstanceId                       string            `json:"instanceId,omitempty" yaml:"instanceId,omitempty"`
+	StatusPageUrl                    string            `json:"statusPageUrl,omitempty" yaml:"statusPageUrl,omitempty"`
+	HomePageUrl                      string            `json:"homePageUrl,omitempty" yaml:"homePageUrl,omitempty"`
+	HealthCheckUrl                   string            `json:"healthCheckUrl,omitempty" yaml:"healthCheckUrl,omitempty"`
+	Hostname                         string            `json:"hostname,omitempty" yaml:"hostname,omitempty"`
+	MetadataMap                      map[string]string `json:"metadataMap,omitempty" yaml:"metadataMap,omitempty"`
+	AmazonMetadataUrl                string            `json:"amazonMetadataUrl,omitempty" yaml:"amazonMetadataUrl,omitempty"`
+	LeaseRenewalIntervalInSeconds    int               `json:"leaseRenewalIntervalInSeconds,omitempty" yaml:"leaseRenewalIntervalInSeconds,omitempty"`
+	LeaseExpirationDurationInSeconds int               `json:"leaseExpirationDurationInSeconds,omitempty" yaml:"leaseExpirationDurationInSeconds,omitempty"`
+	HttpsOnly                        bool              `json:"httpsOnly,omitempty" yaml:"httpsOnly,omitempty"`
+	InstanceIdStrategy               string            `json:"instanceIdStrategy,omitempty" yaml:"instanceIdStrategy,omitempty"`
+	InstanceIdTemplate               string            `json:"instanceIdTemplate,omitempty" yaml:"instanceIdTemplate,omitempty"`
+	initializationProblems           []string
+	securePortConfigured             bool
 }
 
 func newInstanceProperties(environment core.Environment) *InstanceProperties {
@@ -60,15 +236,21 @@ func newInstanceProperties(environment core.Environment) *InstanceProperties {
 		ApplicationGroupName: unknown,
 		DataCenterInfo: DataCenterInfo{
 			DataCenterMyOwn,
-			"com.netflix.appinfo.InstanceInfo$DefaultDataCenterInfo",
+			DefaultDataCenterInfoClass,
+			nil,
 		},
-		SecurePort:           securePort,
-		NonSecurePort:        nonSecurePort,
-		NonSecurePortEnabled: true,
-		SecurePortEnabled:    false,
-		StatusPageUrl:        statusPageUrlPath,
-		HomePageUrl:          homePageUrlPath,
-		HealthCheckUrl:       healthCheckUrlPath,
+		SecurePort:                       securePort,
+		NonSecurePort:                    nonSecurePort,
+		NonSecurePortEnabled:             true,
+		SecurePortEnabled:                false,
+		StatusPageUrl:                    statusPageUrlPath,
+		HomePageUrl:                      homePageUrlPath,
+		HealthCheckUrl:                   healthCheckUrlPath,
+		MetadataMap:                      make(map[string]string),
+		AmazonMetadataUrl:                DefaultAmazonMetadataUrl,
+		LeaseRenewalIntervalInSeconds:    30,
+		LeaseExpirationDurationInSeconds: 90,
+		InstanceIdStrategy:               InstanceIdStrategyHostAppPort,
 	}
 	instanceProperties.initialize(environment)
 	return instanceProperties
@@ -79,21 +261,27 @@ func (instanceProperties *InstanceProperties) initialize(environment core.Enviro
 	instanceProperties.ApplicationName = appName
 	instanceProperties.ApplicationGroupName = appName
 
-	// instance id, hostname and ipAddr
+	// hostname and ipAddr, the instance id is generated from them unless it is configured
 	hostName, err := os.Hostname()
 	if err != nil {
-		panic(err)
+		// reported by the validation instead of crashing the application
+		instanceProperties.initializationProblems = append(instanceProperties.initializationProblems, "hostname could not be resolved: "+err.Error())
 	}
 	instanceProperties.Hostname = hostName
 	instanceProperties.IpAddr = instanceProperties.getFirstNonLoopbackIpAddr(hostName)
 
-	namePart := instanceProperties.combineParts(hostName, appName, ":")
+	// the server port is only advertised as the secure one if no secure port is configured
+	instanceProperties.securePortConfigured = environment.ContainsProperty(instanceProperties.GetConfigurationPrefix() + ".securePort")
+
 	port := environment.GetProperty("server.port", "8080").(string)
-	instanceProperties.InstanceId = instanceProperties.combineParts(namePart, port, ":")
 
 	// non secure port
 	var parsedPort int64
 	parsedPort, err = strconv.ParseInt(port, 10, 32)
+	if err != nil {
+		instanceProperties.initializationProblems = append(instanceProperties.initializationProblems, "server.port is not a valid port: "+port)
+		return
+	}
 	instanceProperties.NonSecurePort = int(parsedPort)
 }
 
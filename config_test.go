@@ -0,0 +1,133 @@
+package eureka
+
+import "testing"
+
+func TestClientProperties_GetEurekaServiceUrls(t *testing.T) {
+	testCases := []struct {
+		name                 string
+		region               string
+		availabilityZones    map[string]string
+		serviceUrl           map[string]string
+		preferSameZoneEureka bool
+		instanceZone         string
+		expected             []string
+	}{
+		{
+			name: "default zone",
+			serviceUrl: map[string]string{
+				DefaultZone: "http://localhost:8761/eureka",
+			},
+			expected: []string{"http://localhost:8761/eureka/"},
+		},
+		{
+			name:     "default url",
+			expected: []string{DefaultUrl},
+		},
+		{
+			name:              "zones in the configured order",
+			region:            "us-east-1",
+			availabilityZones: map[string]string{"us-east-1": "us-east-1a, us-east-1b,us-east-1c"},
+			serviceUrl: map[string]string{
+				"us-east-1a": "http://a1/eureka/,http://a2/eureka/",
+				"us-east-1b": "http://b1/eureka/",
+				"us-east-1c": "http://c1/eureka/",
+			},
+			instanceZone: "us-east-1b",
+			expected:     []string{"http://a1/eureka/", "http://a2/eureka/", "http://b1/eureka/", "http://c1/eureka/"},
+		},
+		{
+			name:              "same zone first and the rest wrapped around",
+			region:            "us-east-1",
+			availabilityZones: map[string]string{"us-east-1": "us-east-1a,us-east-1b,us-east-1c"},
+			serviceUrl: map[string]string{
+				"us-east-1a": "http://a1/eureka/",
+				"us-east-1b": "http://b1/eureka/",
+				"us-east-1c": "http://c1/eureka/",
+			},
+			preferSameZoneEureka: true,
+			instanceZone:         "us-east-1b",
+			expected:             []string{"http://b1/eureka/", "http://c1/eureka/", "http://a1/eureka/"},
+		},
+		{
+			name:              "unknown instance zone",
+			region:            "us-east-1",
+			availabilityZones: map[string]string{"us-east-1": "us-east-1a,us-east-1b"},
+			serviceUrl: map[string]string{
+				"us-east-1a": "http://a1/eureka/",
+				"us-east-1b": "http://b1/eureka/",
+			},
+			preferSameZoneEureka: true,
+			instanceZone:         "us-east-1d",
+			expected:             []string{"http://a1/eureka/", "http://b1/eureka/"},
+		},
+		{
+			name:              "zones without urls fall back to the default zone",
+			region:            "us-east-1",
+			availabilityZones: map[string]string{"us-east-1": "us-east-1a"},
+			serviceUrl: map[string]string{
+				DefaultZone: "http://localhost:8761/eureka/",
+			},
+			preferSameZoneEureka: true,
+			instanceZone:         "us-east-1a",
+			expected:             []string{"http://localhost:8761/eureka/"},
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			clientProperties := newClientProperties()
+			clientProperties.Region = testCase.region
+			clientProperties.AvailabilityZones = testCase.availabilityZones
+			clientProperties.ServiceUrl = testCase.serviceUrl
+			clientProperties.PreferSameZoneEureka = testCase.preferSameZoneEureka
+
+			serviceUrls := clientProperties.GetEurekaServiceUrls(testCase.instanceZone)
+			if len(serviceUrls) != len(testCase.expected) {
+				t.Fatalf("expected the urls %v, got %v", testCase.expected, serviceUrls)
+			}
+			for index, serviceUrl := range serviceUrls {
+				if serviceUrl != testCase.expected[index] {
+					t.Errorf("expected the urls %v, got %v", testCase.expected, serviceUrls)
+				}
+			}
+		})
+	}
+}
+
+func TestClientProperties_GetZone(t *testing.T) {
+	testCases := []struct {
+		name              string
+		metadata          map[string]string
+		availabilityZones map[string]string
+		expected          string
+	}{
+		{
+			name:     "zone from the metadata",
+			metadata: map[string]string{ZoneKey: "us-east-1b"},
+			expected: "us-east-1b",
+		},
+		{
+			name:              "first zone of the region",
+			availabilityZones: map[string]string{"us-east-1": "us-east-1a,us-east-1b"},
+			expected:          "us-east-1a",
+		},
+		{
+			name:     "default zone",
+			expected: DefaultZone,
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			clientProperties := newClientProperties()
+			clientProperties.Region = "us-east-1"
+			clientProperties.AvailabilityZones = testCase.availabilityZones
+			instanceProperties := newValidInstanceProperties()
+			instanceProperties.MetadataMap = testCase.metadata
+
+			if zone := clientProperties.GetZone(&instanceProperties); zone != testCase.expected {
+				t.Errorf("expected the zone %q, got %q", testCase.expected, zone)
+			}
+		})
+	}
+}
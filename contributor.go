@@ -0,0 +1,58 @@
+package eureka
+
+import "sync"
+
+type InstanceInfoContributor interface {
+	Contribute(instanceInfo *InstanceInfo) error
+}
+
+type amazonInstanceInfoContributor struct {
+	collector      *AmazonInfoCollector
+	zoneConfigured bool
+	amazonInfo     *AmazonInfo
+	amazonInfoMu   sync.Mutex
+}
+
+func newAmazonInstanceInfoContributor(instanceProperties InstanceProperties) *amazonInstanceInfoContributor {
+	_, zoneConfigured := instanceProperties.MetadataMap[ZoneKey]
+	return &amazonInstanceInfoContributor{
+		collector:      NewAmazonInfoCollector(instanceProperties.AmazonMetadataUrl),
+		zoneConfigured: zoneConfigured,
+	}
+}
+
+func (contributor *amazonInstanceInfoContributor) Contribute(instanceInfo *InstanceInfo) error {
+	// whatever could be collected is still published even if some keys failed
+	amazonInfo, err := contributor.collect()
+
+	if instanceInfo.DataCenterInfo == nil {
+		instanceInfo.DataCenterInfo = &DataCenterInfo{}
+	}
+	instanceInfo.DataCenterInfo.Name = DataCenterAmazon
+	instanceInfo.DataCenterInfo.Class = AmazonInfoClass
+	instanceInfo.DataCenterInfo.Metadata = amazonInfo
+
+	if !contributor.zoneConfigured && amazonInfo.AvailabilityZone != "" {
+		if instanceInfo.Metadata == nil {
+			instanceInfo.Metadata = make(map[string]string)
+		}
+		instanceInfo.Metadata[ZoneKey] = amazonInfo.AvailabilityZone
+	}
+	return err
+}
+
+func (contributor *amazonInstanceInfoContributor) collect() (*AmazonInfo, error) {
+	contributor.amazonInfoMu.Lock()
+	defer contributor.amazonInfoMu.Unlock()
+	// the metadata stays the same as long as the instance lives, it is collected again only after a failure
+	if contributor.amazonInfo == nil {
+		amazonInfo, err := contributor.collector.Collect()
+		if err != nil {
+			return amazonInfo, err
+		}
+		contributor.amazonInfo = amazonInfo
+	}
+
+	amazonInfo := *contributor.amazonInfo
+	return &amazonInfo, nil
+}
@@ -0,0 +1,127 @@
+package eureka
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"net/http"
+	"strings"
+	"time"
+)
+
+type RegionSnapshot struct {
+	Region       string        `json:"region" xml:"region"`
+	Applications *Applications `json:"applications" xml:"applications"`
+}
+
+type RegistrySnapshot struct {
+	XMLName            xml.Name           `json:"-" xml:"registry"`
+	AppsHashcode       string             `json:"appsHashcode" xml:"appsHashcode"`
+	ViewHashcode       string             `json:"viewHashcode" xml:"viewHashcode"`
+	LastFetchTime      *time.Time         `json:"lastFetchTime,omitempty" xml:"lastFetchTime,omitempty"`
+	LastDeltaVersion   string             `json:"lastDeltaVersion,omitempty" xml:"lastDeltaVersion,omitempty"`
+	Stale              bool               `json:"stale" xml:"stale"`
+	HashcodeMismatches []HashcodeMismatch `json:"hashcodeMismatches" xml:"hashcodeMismatches>hashcodeMismatch"`
+	Applications       *Applications      `json:"applications" xml:"applications"`
+	Regions            []RegionSnapshot   `json:"regions,omitempty" xml:"regions>region,omitempty"`
+}
+
+type RegistryDebugHandler struct {
+	registryCache *RegistryCache
+}
+
+func newRegistryDebugHandler(registryCache *RegistryCache) RegistryDebugHandler {
+	return RegistryDebugHandler{
+		registryCache,
+	}
+}
+
+func (debugHandler RegistryDebugHandler) ServeHTTP(writer http.ResponseWriter, request *http.Request) {
+	if request.Method != http.MethodGet && request.Method != http.MethodHead {
+		writer.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	query := request.URL.Query()
+	snapshot := debugHandler.GetSnapshot(query.Get("app"), InstanceStatus(strings.ToUpper(query.Get("status"))))
+
+	if debugHandler.isXmlRequested(request) {
+		writer.Header().Set("Content-Type", "application/xml")
+		writer.WriteHeader(http.StatusOK)
+		_ = xml.NewEncoder(writer).Encode(snapshot)
+		return
+	}
+
+	writer.Header().Set("Content-Type", "application/json")
+	writer.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(writer).Encode(snapshot)
+}
+
+func (debugHandler RegistryDebugHandler) GetSnapshot(appName string, status InstanceStatus) RegistrySnapshot {
+	applications := debugHandler.registryCache.GetApplications()
+
+	snapshot := RegistrySnapshot{
+		AppsHashcode:       debugHandler.registryCache.GetAppsHashcode(),
+		LastDeltaVersion:   debugHandler.registryCache.GetLastDeltaVersion(),
+		Stale:              debugHandler.registryCache.IsStale(),
+		HashcodeMismatches: debugHandler.registryCache.GetHashcodeMismatches(),
+		Applications:       debugHandler.filter(applications, appName, status),
+	}
+
+	// the view can differ from the server's registry, the filters and the self preservation change it
+	if applications != nil {
+		snapshot.ViewHashcode = applications.AppsHashcode
+	}
+
+	if lastFetchTime := debugHandler.registryCache.GetLastFetchTime(); !lastFetchTime.IsZero() {
+		snapshot.LastFetchTime = &lastFetchTime
+	}
+
+	for _, region := range debugHandler.registryCache.GetRemoteRegions() {
+		snapshot.Regions = append(snapshot.Regions, RegionSnapshot{
+			Region:       region,
+			Applications: debugHandler.filter(debugHandler.registryCache.GetApplicationsForRegion(region), appName, status),
+		})
+	}
+	return snapshot
+}
+
+func (debugHandler RegistryDebugHandler) filter(applications *Applications, appName string, status InstanceStatus) *Applications {
+	filtered := &Applications{
+		Applications: make([]Application, 0),
+	}
+	if applications == nil {
+		return filtered
+	}
+
+	filtered.VersionsDelta = applications.VersionsDelta
+	filtered.AppsHashcode = applications.AppsHashcode
+
+	for _, application := range applications.Applications {
+		if appName != "" && !strings.EqualFold(application.Name, appName) {
+			continue
+		}
+
+		instances := make([]InstanceInfo, 0)
+		for _, instance := range application.Instances {
+			if status == "" || instance.Status == status {
+				instances = append(instances, instance)
+			}
+		}
+
+		if len(instances) != 0 {
+			filtered.Applications = append(filtered.Applications, Application{
+				Name:      application.Name,
+				Instances: instances,
+			})
+		}
+	}
+	return filtered
+}
+
+func (debugHandler RegistryDebugHandler) isXmlRequested(request *http.Request) bool {
+	if format := request.URL.Query().Get("format"); format != "" {
+		return strings.EqualFold(format, "xml")
+	}
+	accept := request.Header.Get("Accept")
+	return strings.Contains(accept, "xml") && !strings.Contains(accept, "json")
+}
@@ -0,0 +1,184 @@
+package eureka
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"testing"
+)
+
+func TestRegistryDebugHandler_ServeHTTP(t *testing.T) {
+	testCases := []struct {
+		name                string
+		method              string
+		target              string
+		accept              string
+		expectedStatus      int
+		expectedContentType string
+		expectedIds         []string
+		expectedRegionIds   []string
+	}{
+		{
+			name:                "json by default",
+			method:              http.MethodGet,
+			target:              "/",
+			expectedStatus:      http.StatusOK,
+			expectedContentType: "application/json",
+			expectedIds:         []string{"orders-1", "orders-2", "payments-1"},
+			expectedRegionIds:   []string{"remote-1"},
+		},
+		{
+			name:                "xml format",
+			method:              http.MethodGet,
+			target:              "/?format=xml",
+			expectedStatus:      http.StatusOK,
+			expectedContentType: "application/xml",
+			expectedIds:         []string{"orders-1", "orders-2", "payments-1"},
+			expectedRegionIds:   []string{"remote-1"},
+		},
+		{
+			name:                "xml accept header",
+			method:              http.MethodGet,
+			target:              "/",
+			accept:              "application/xml",
+			expectedStatus:      http.StatusOK,
+			expectedContentType: "application/xml",
+			expectedIds:         []string{"orders-1", "orders-2", "payments-1"},
+			expectedRegionIds:   []string{"remote-1"},
+		},
+		{
+			name:                "format overrides the accept header",
+			method:              http.MethodGet,
+			target:              "/?format=json",
+			accept:              "application/xml",
+			expectedStatus:      http.StatusOK,
+			expectedContentType: "application/json",
+			expectedIds:         []string{"orders-1", "orders-2", "payments-1"},
+			expectedRegionIds:   []string{"remote-1"},
+		},
+		{
+			name:                "app and status filters",
+			method:              http.MethodGet,
+			target:              "/?app=orders&status=down",
+			expectedStatus:      http.StatusOK,
+			expectedContentType: "application/json",
+			expectedIds:         []string{"orders-2"},
+		},
+		{
+			name:                "app filter in xml",
+			method:              http.MethodGet,
+			target:              "/?app=payments&format=xml",
+			expectedStatus:      http.StatusOK,
+			expectedContentType: "application/xml",
+			expectedIds:         []string{"payments-1"},
+		},
+		{
+			name:           "method not allowed",
+			method:         http.MethodPost,
+			target:         "/",
+			expectedStatus: http.StatusMethodNotAllowed,
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			server := newFakeEurekaServer(&Applications{
+				Applications: []Application{
+					{
+						Name: "ORDERS",
+						Instances: []InstanceInfo{
+							newTestInstance("ORDERS", "orders-1", InstanceStatusUp, ""),
+							newTestInstance("ORDERS", "orders-2", InstanceStatusDown, ""),
+						},
+					},
+					{
+						Name: "PAYMENTS",
+						Instances: []InstanceInfo{
+							newTestInstance("PAYMENTS", "payments-1", InstanceStatusUp, ""),
+						},
+					},
+				},
+			})
+			defer server.Close()
+			server.setRemoteApplications("us-west-2", &Applications{
+				Applications: []Application{
+					{
+						Name: "ORDERS",
+						Instances: []InstanceInfo{
+							newTestInstance("ORDERS", "remote-1", InstanceStatusUp, ""),
+						},
+					},
+				},
+			})
+
+			clientProperties := newClientProperties()
+			clientProperties.ServiceUrl = map[string]string{DefaultZone: server.serviceUrl()}
+			clientProperties.FetchRemoteRegionsRegistry = "us-west-2"
+			clientProperties.FilterOnlyUpInstances = false
+			registryCache := newRegistryCache(NewDefaultHttpClient(server.serviceUrl()), *clientProperties, nil, nil)
+			if err := registryCache.Refresh(); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			request := httptest.NewRequest(testCase.method, testCase.target, nil)
+			if testCase.accept != "" {
+				request.Header.Set("Accept", testCase.accept)
+			}
+			recorder := httptest.NewRecorder()
+			newRegistryDebugHandler(registryCache).ServeHTTP(recorder, request)
+
+			if recorder.Code != testCase.expectedStatus {
+				t.Fatalf("expected the status %d, got %d", testCase.expectedStatus, recorder.Code)
+			}
+			if testCase.expectedStatus != http.StatusOK {
+				return
+			}
+			if contentType := recorder.Header().Get("Content-Type"); contentType != testCase.expectedContentType {
+				t.Errorf("expected the content type %s, got %s", testCase.expectedContentType, contentType)
+			}
+
+			snapshot := RegistrySnapshot{}
+			var err error
+			if strings.HasSuffix(testCase.expectedContentType, "xml") {
+				err = xml.Unmarshal(recorder.Body.Bytes(), &snapshot)
+			} else {
+				err = json.Unmarshal(recorder.Body.Bytes(), &snapshot)
+			}
+			if err != nil {
+				t.Fatalf("snapshot could not be decoded: %v\n%s", err, recorder.Body.String())
+			}
+
+			if snapshot.AppsHashcode != registryCache.GetAppsHashcode() {
+				t.Errorf("expected the apps hashcode %q, got %q", registryCache.GetAppsHashcode(), snapshot.AppsHashcode)
+			}
+			if snapshot.LastFetchTime == nil {
+				t.Errorf("expected the last fetch time")
+			}
+
+			assertInstanceIds := func(applications *Applications, expectedIds []string) {
+				instanceIds := make([]string, 0)
+				if applications != nil {
+					for _, application := range applications.Applications {
+						for _, instance := range application.Instances {
+							instanceIds = append(instanceIds, instance.InstanceId)
+						}
+					}
+				}
+				// the view shuffles the instances
+				sort.Strings(instanceIds)
+				if strings.Join(instanceIds, ",") != strings.Join(expectedIds, ",") {
+					t.Errorf("expected the instances %v, got %v", expectedIds, instanceIds)
+				}
+			}
+			assertInstanceIds(snapshot.Applications, testCase.expectedIds)
+
+			if len(snapshot.Regions) != 1 || snapshot.Regions[0].Region != "us-west-2" {
+				t.Fatalf("expected the us-west-2 region, got %+v", snapshot.Regions)
+			}
+			assertInstanceIds(snapshot.Regions[0].Applications, testCase.expectedRegionIds)
+		})
+	}
+}
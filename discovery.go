@@ -2,15 +2,22 @@ package eureka
 
 import (
 	cloud "github.com/procyon-projects/procyon-cloud"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
 )
 
 type DiscoveryClient struct {
-	httpClient HttpClient
+	registryCache        *RegistryCache
+	instanceInfoProvider InstanceInfoProvider
 }
 
-func newDiscoveryClient(httpClient HttpClient) DiscoveryClient {
+// the client properties are read from the registry cache, so the refreshed ones are used
+func newDiscoveryClient(registryCache *RegistryCache, instanceInfoManager *InstanceInfoManager) DiscoveryClient {
 	return DiscoveryClient{
-		httpClient,
+		registryCache,
+		instanceInfoManager,
 	}
 }
 
@@ -19,21 +26,139 @@ func (discoveryClient DiscoveryClient) GetDescription() string {
 }
 
 func (discoveryClient DiscoveryClient) GetServiceInstances(serviceId string) []cloud.ServiceInstance {
-	return nil
+	instances := discoveryClient.getInstances(discoveryClient.registryCache.GetApplications(), serviceId)
+
+	if !discoveryClient.hasUpInstance(instances) {
+		// fall back to the remote regions in the configured order
+		for _, region := range discoveryClient.registryCache.GetRemoteRegions() {
+			regionInstances := discoveryClient.getInstances(discoveryClient.registryCache.GetApplicationsForRegion(region), serviceId)
+			if discoveryClient.hasUpInstance(regionInstances) {
+				instances = regionInstances
+				break
+			}
+		}
+	}
+
+	if !discoveryClient.hasUpInstance(instances) || discoveryClient.isEurekaUnreachable() {
+		if fallbackInstances := discoveryClient.getFallbackInstances(serviceId); len(fallbackInstances) != 0 {
+			instances = fallbackInstances
+		}
+	}
+
+	discoveryClient.sortByZone(instances)
+
+	serviceInstances := make([]cloud.ServiceInstance, 0, len(instances))
+	for index := range instances {
+		serviceInstances = append(serviceInstances, newServiceInstance(&instances[index]))
+	}
+	return serviceInstances
 }
 
 func (discoveryClient DiscoveryClient) GetServices() []string {
-	applications, err := discoveryClient.httpClient.GetApplications()
-
 	names := make([]string, 0)
+	seen := make(map[string]bool)
 
-	if err != nil {
-		return names
+	applicationsList := []*Applications{discoveryClient.registryCache.GetApplications()}
+	for _, region := range discoveryClient.registryCache.GetRemoteRegions() {
+		applicationsList = append(applicationsList, discoveryClient.registryCache.GetApplicationsForRegion(region))
 	}
 
-	for _, application := range applications.Applications {
-		names = append(names, application.Name)
+	for _, applications := range applicationsList {
+		if applications == nil {
+			continue
+		}
+		for _, application := range applications.Applications {
+			if !seen[application.Name] {
+				seen[application.Name] = true
+				names = append(names, application.Name)
+			}
+		}
 	}
 
 	return names
 }
+
+func (discoveryClient DiscoveryClient) getInstances(applications *Applications, serviceId string) []InstanceInfo {
+	application := applications.GetApplication(serviceId)
+	if application == nil {
+		return nil
+	}
+	return append([]InstanceInfo(nil), application.Instances...)
+}
+
+func (discoveryClient DiscoveryClient) isEurekaUnreachable() bool {
+	clientProperties := discoveryClient.registryCache.getClientProperties()
+	if clientProperties.FallbackThresholdSeconds <= 0 {
+		return false
+	}
+
+	unreachableSince := discoveryClient.registryCache.getUnreachableSince()
+	if unreachableSince.IsZero() {
+		return false
+	}
+	threshold := time.Duration(clientProperties.FallbackThresholdSeconds) * time.Second
+	return time.Since(unreachableSince) > threshold
+}
+
+func (discoveryClient DiscoveryClient) getFallbackInstances(serviceId string) []InstanceInfo {
+	clientProperties := discoveryClient.registryCache.getClientProperties()
+	fallbackInstances := clientProperties.GetFallbackInstances(serviceId)
+	instances := make([]InstanceInfo, 0, len(fallbackInstances))
+
+	for _, fallbackInstance := range fallbackInstances {
+		metadata := make(MetadataMap)
+		for key, value := range fallbackInstance.Metadata {
+			metadata[key] = value
+		}
+		// callers can tell the static instances from the registered ones
+		metadata[FallbackKey] = "true"
+
+		instance := InstanceInfo{
+			InstanceId: fallbackInstance.Host + ":" + strconv.Itoa(fallbackInstance.Port),
+			AppName:    strings.ToUpper(serviceId),
+			IpAddr:     fallbackInstance.Host,
+			HostName:   fallbackInstance.Host,
+			Status:     InstanceStatusUp,
+			Metadata:   metadata,
+		}
+
+		if fallbackInstance.Secure {
+			instance.SecurePort = &PortWrapper{
+				Enabled: "true",
+				Port:    fallbackInstance.Port,
+			}
+		} else {
+			instance.Port = &PortWrapper{
+				Enabled: "true",
+				Port:    fallbackInstance.Port,
+			}
+		}
+		instances = append(instances, instance)
+	}
+	return instances
+}
+
+func (discoveryClient DiscoveryClient) hasUpInstance(instances []InstanceInfo) bool {
+	for _, instance := range instances {
+		if instance.Status == InstanceStatusUp {
+			return true
+		}
+	}
+	return false
+}
+
+func (discoveryClient DiscoveryClient) sortByZone(instances []InstanceInfo) {
+	if discoveryClient.instanceInfoProvider == nil {
+		return
+	}
+
+	zone := discoveryClient.instanceInfoProvider.GetInstanceInfo().GetZone()
+	if zone == "" {
+		return
+	}
+
+	// the instances in the same zone come first
+	sort.SliceStable(instances, func(i, j int) bool {
+		return instances[i].GetZone() == zone && instances[j].GetZone() != zone
+	})
+}
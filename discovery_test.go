@@ -0,0 +1,242 @@
+package eureka
+
+import (
+	"testing"
+	"time"
+)
+
+func TestDiscoveryClient_IsEurekaUnreachable(t *testing.T) {
+	now := time.Now()
+	testCases := []struct {
+		name             string
+		thresholdSeconds int
+		firstAttemptTime time.Time
+		lastFetchTime    time.Time
+		expected         bool
+	}{
+		{
+			name:             "no threshold",
+			firstAttemptTime: now.Add(-time.Hour),
+		},
+		{
+			name:             "never fetched",
+			thresholdSeconds: 60,
+		},
+		{
+			name:             "no successful fetch since the first attempt",
+			thresholdSeconds: 60,
+			firstAttemptTime: now.Add(-2 * time.Minute),
+			expected:         true,
+		},
+		{
+			name:             "first attempt within the threshold",
+			thresholdSeconds: 60,
+			firstAttemptTime: now.Add(-30 * time.Second),
+		},
+		{
+			name:             "last fetch within the threshold",
+			thresholdSeconds: 60,
+			firstAttemptTime: now.Add(-time.Hour),
+			lastFetchTime:    now.Add(-30 * time.Second),
+		},
+		{
+			name:             "last fetch older than the threshold",
+			thresholdSeconds: 60,
+			firstAttemptTime: now.Add(-time.Hour),
+			lastFetchTime:    now.Add(-2 * time.Minute),
+			expected:         true,
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			discoveryClient := DiscoveryClient{
+				registryCache: &RegistryCache{
+					firstAttemptTime: testCase.firstAttemptTime,
+					lastFetchTime:    testCase.lastFetchTime,
+					clientProperties: ClientProperties{
+						FallbackThresholdSeconds: testCase.thresholdSeconds,
+					},
+				},
+			}
+			if unreachable := discoveryClient.isEurekaUnreachable(); unreachable != testCase.expected {
+				t.Errorf("expected %v, got %v", testCase.expected, unreachable)
+			}
+		})
+	}
+}
+
+func TestDiscoveryClient_GetServiceInstancesFromRemoteRegions(t *testing.T) {
+	testCases := []struct {
+		name          string
+		local         []InstanceInfo
+		remote        []InstanceInfo
+		expectedIds   []string
+		expectedNames []string
+	}{
+		{
+			name: "local instances first",
+			local: []InstanceInfo{
+				newTestInstance("ORDERS", "local-1", InstanceStatusUp, ""),
+			},
+			remote: []InstanceInfo{
+				newTestInstance("ORDERS", "remote-1", InstanceStatusUp, ""),
+			},
+			expectedIds:   []string{"local-1"},
+			expectedNames: []string{"ORDERS"},
+		},
+		{
+			name: "remote region when no local instance is up",
+			local: []InstanceInfo{
+				newTestInstance("ORDERS", "local-1", InstanceStatusDown, ""),
+			},
+			remote: []InstanceInfo{
+				newTestInstance("ORDERS", "remote-1", InstanceStatusUp, ""),
+			},
+			expectedIds:   []string{"remote-1"},
+			expectedNames: []string{"ORDERS"},
+		},
+		{
+			name: "remote region only",
+			remote: []InstanceInfo{
+				newTestInstance("ORDERS", "remote-1", InstanceStatusUp, ""),
+			},
+			expectedIds:   []string{"remote-1"},
+			expectedNames: []string{"ORDERS"},
+		},
+		{
+			name: "local instances in the remote view are left out",
+			local: []InstanceInfo{
+				newTestInstance("ORDERS", "shared-1", InstanceStatusDown, ""),
+			},
+			remote: []InstanceInfo{
+				newTestInstance("ORDERS", "shared-1", InstanceStatusUp, ""),
+			},
+			expectedIds:   []string{"shared-1"},
+			expectedNames: []string{"ORDERS"},
+		},
+		{
+			name: "no instance is up anywhere",
+			local: []InstanceInfo{
+				newTestInstance("ORDERS", "local-1", InstanceStatusDown, ""),
+			},
+			remote: []InstanceInfo{
+				newTestInstance("ORDERS", "remote-1", InstanceStatusOutOfService, ""),
+			},
+			expectedIds:   []string{"local-1"},
+			expectedNames: []string{"ORDERS"},
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			newApplications := func(instances []InstanceInfo) *Applications {
+				if len(instances) == 0 {
+					return &Applications{}
+				}
+				return &Applications{Applications: []Application{{Name: "ORDERS", Instances: instances}}}
+			}
+
+			server := newFakeEurekaServer(newApplications(testCase.local))
+			defer server.Close()
+			server.setRemoteApplications("us-west-2", newApplications(testCase.remote))
+
+			clientProperties := newClientProperties()
+			clientProperties.ServiceUrl = map[string]string{DefaultZone: server.serviceUrl()}
+			clientProperties.FetchRemoteRegionsRegistry = "us-west-2"
+			// keeps the instances which are not up, so the fallback is decided by the discovery client
+			clientProperties.FilterOnlyUpInstances = false
+
+			registryCache := newRegistryCache(NewDefaultHttpClient(server.serviceUrl()), *clientProperties, nil, nil)
+			if err := registryCache.Refresh(); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			discoveryClient := DiscoveryClient{registryCache: registryCache}
+
+			serviceInstances := discoveryClient.GetServiceInstances("orders")
+			if len(serviceInstances) != len(testCase.expectedIds) {
+				t.Fatalf("expected %d instances, got %d", len(testCase.expectedIds), len(serviceInstances))
+			}
+			for index, serviceInstance := range serviceInstances {
+				if serviceInstance.GetInstanceId() != testCase.expectedIds[index] {
+					t.Errorf("expected the instance %s, got %s", testCase.expectedIds[index], serviceInstance.GetInstanceId())
+				}
+			}
+
+			services := discoveryClient.GetServices()
+			if len(services) != len(testCase.expectedNames) {
+				t.Fatalf("expected the services %v, got %v", testCase.expectedNames, services)
+			}
+			for index, service := range services {
+				if service != testCase.expectedNames[index] {
+					t.Errorf("expected the services %v, got %v", testCase.expectedNames, services)
+				}
+			}
+		})
+	}
+}
+
+func TestDiscoveryClient_SortByZone(t *testing.T) {
+	withZone := func(instanceId string, zone string) InstanceInfo {
+		return withMetadata(newTestInstance("ORDERS", instanceId, InstanceStatusUp, ""), MetadataMap{ZoneKey: zone})
+	}
+
+	testCases := []struct {
+		name        string
+		zone        string
+		instances   []InstanceInfo
+		expectedIds []string
+	}{
+		{
+			name: "same zone first",
+			zone: "us-east-1b",
+			instances: []InstanceInfo{
+				withZone("a-1", "us-east-1a"),
+				withZone("b-1", "us-east-1b"),
+				withZone("c-1", "us-east-1c"),
+				withZone("b-2", "us-east-1b"),
+			},
+			expectedIds: []string{"b-1", "b-2", "a-1", "c-1"},
+		},
+		{
+			name: "no instance in the same zone",
+			zone: "us-east-1d",
+			instances: []InstanceInfo{
+				withZone("a-1", "us-east-1a"),
+				withZone("b-1", "us-east-1b"),
+			},
+			expectedIds: []string{"a-1", "b-1"},
+		},
+		{
+			name: "zone from the data center info",
+			zone: "us-east-1b",
+			instances: []InstanceInfo{
+				withZone("a-1", "us-east-1a"),
+				func() InstanceInfo {
+					instance := newTestInstance("ORDERS", "b-1", InstanceStatusUp, "")
+					instance.DataCenterInfo = &DataCenterInfo{
+						Metadata: &AmazonInfo{AvailabilityZone: "us-east-1b"},
+					}
+					return instance
+				}(),
+			},
+			expectedIds: []string{"b-1", "a-1"},
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			manager := newTestInstanceInfoManager()
+			manager.SetMetadata(ZoneKey, testCase.zone)
+			discoveryClient := DiscoveryClient{instanceInfoProvider: manager}
+
+			instances := append([]InstanceInfo(nil), testCase.instances...)
+			discoveryClient.sortByZone(instances)
+			for index, instance := range instances {
+				if instance.InstanceId != testCase.expectedIds[index] {
+					t.Errorf("expected the order %v, got %s at %d", testCase.expectedIds, instance.InstanceId, index)
+				}
+			}
+		})
+	}
+}
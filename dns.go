@@ -0,0 +1,245 @@
+package eureka
+
+import (
+	"errors"
+	"net"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const defaultServiceUrlPollInterval = 5 * time.Minute
+
+type ServiceUrlProvider interface {
+	GetServiceUrls() []string
+}
+
+type StaticServiceUrlProvider []string
+
+func (serviceUrlProvider StaticServiceUrlProvider) GetServiceUrls() []string {
+	return serviceUrlProvider
+}
+
+type RefreshableServiceUrlProvider struct {
+	serviceUrls   []string
+	serviceUrlsMu sync.RWMutex
+}
+
+func NewRefreshableServiceUrlProvider(serviceUrls ...string) *RefreshableServiceUrlProvider {
+	return &RefreshableServiceUrlProvider{
+		serviceUrls: serviceUrls,
+	}
+}
+
+func (serviceUrlProvider *RefreshableServiceUrlProvider) GetServiceUrls() []string {
+	serviceUrlProvider.serviceUrlsMu.RLock()
+	defer serviceUrlProvider.serviceUrlsMu.RUnlock()
+	return append([]string(nil), serviceUrlProvider.serviceUrls...)
+}
+
+func (serviceUrlProvider *RefreshableServiceUrlProvider) SetServiceUrls(serviceUrls []string) bool {
+	serviceUrlProvider.serviceUrlsMu.Lock()
+	defer serviceUrlProvider.serviceUrlsMu.Unlock()
+	if reflect.DeepEqual(serviceUrlProvider.serviceUrls, serviceUrls) {
+		return false
+	}
+	serviceUrlProvider.serviceUrls = append([]string(nil), serviceUrls...)
+	return true
+}
+
+type TxtResolver interface {
+	LookupTXT(name string) ([]string, error)
+}
+
+type netTxtResolver struct {
+}
+
+func (resolver netTxtResolver) LookupTXT(name string) ([]string, error) {
+	return net.LookupTXT(name)
+}
+
+type DnsServiceUrlProvider struct {
+	clientProperties ClientProperties
+	instanceZone     string
+	resolver         TxtResolver
+	logger           Logger
+	serviceUrls      []string
+	serviceUrlsMu    sync.RWMutex
+	lifecycleMu      sync.Mutex
+	stopCh           chan struct{}
+}
+
+func NewDnsServiceUrlProvider(clientProperties ClientProperties, instanceZone string) *DnsServiceUrlProvider {
+	return &DnsServiceUrlProvider{
+		clientProperties: clientProperties,
+		instanceZone:     instanceZone,
+		resolver:         netTxtResolver{},
+		logger:           NoOpLogger{},
+	}
+}
+
+func (serviceUrlProvider *DnsServiceUrlProvider) SetTxtResolver(resolver TxtResolver) {
+	serviceUrlProvider.lifecycleMu.Lock()
+	defer serviceUrlProvider.lifecycleMu.Unlock()
+	if resolver == nil {
+		resolver = netTxtResolver{}
+	}
+	serviceUrlProvider.resolver = resolver
+}
+
+func (serviceUrlProvider *DnsServiceUrlProvider) SetLogger(logger Logger) {
+	serviceUrlProvider.lifecycleMu.Lock()
+	defer serviceUrlProvider.lifecycleMu.Unlock()
+	serviceUrlProvider.logger = wrapLogger(logger)
+}
+
+func (serviceUrlProvider *DnsServiceUrlProvider) Start() error {
+	err := serviceUrlProvider.Refresh()
+
+	serviceUrlProvider.lifecycleMu.Lock()
+	defer serviceUrlProvider.lifecycleMu.Unlock()
+	if serviceUrlProvider.stopCh != nil {
+		return err
+	}
+	serviceUrlProvider.stopCh = make(chan struct{})
+
+	interval := time.Duration(serviceUrlProvider.clientProperties.EurekaServiceUrlPollIntervalSeconds) * time.Second
+	if interval <= 0 {
+		interval = defaultServiceUrlPollInterval
+	}
+	go serviceUrlProvider.run(serviceUrlProvider.stopCh, interval)
+	return err
+}
+
+func (serviceUrlProvider *DnsServiceUrlProvider) Stop() {
+	serviceUrlProvider.lifecycleMu.Lock()
+	defer serviceUrlProvider.lifecycleMu.Unlock()
+	if serviceUrlProvider.stopCh != nil {
+		close(serviceUrlProvider.stopCh)
+		serviceUrlProvider.stopCh = nil
+	}
+}
+
+func (serviceUrlProvider *DnsServiceUrlProvider) run(stopCh chan struct{}, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stopCh:
+			return
+		case <-ticker.C:
+			_ = serviceUrlProvider.Refresh()
+		}
+	}
+}
+
+func (serviceUrlProvider *DnsServiceUrlProvider) Refresh() error {
+	serviceUrlProvider.lifecycleMu.Lock()
+	resolver := serviceUrlProvider.resolver
+	logger := serviceUrlProvider.logger
+	serviceUrlProvider.lifecycleMu.Unlock()
+
+	serviceUrls, err := serviceUrlProvider.resolve(resolver, logger)
+	if err != nil {
+		// the last resolved urls are still better than nothing
+		logger.Warning("service urls could not be resolved from dns", Fields{
+			FieldRegion: serviceUrlProvider.clientProperties.Region,
+		}.withError(err))
+		return err
+	}
+
+	serviceUrlProvider.serviceUrlsMu.Lock()
+	serviceUrlProvider.serviceUrls = serviceUrls
+	serviceUrlProvider.serviceUrlsMu.Unlock()
+
+	logger.Debug("service urls resolved from dns", Fields{
+		FieldRegion:     serviceUrlProvider.clientProperties.Region,
+		FieldServiceUrl: strings.Join(serviceUrls, ","),
+	})
+	return nil
+}
+
+func (serviceUrlProvider *DnsServiceUrlProvider) GetServiceUrls() []string {
+	serviceUrlProvider.serviceUrlsMu.RLock()
+	defer serviceUrlProvider.serviceUrlsMu.RUnlock()
+	if len(serviceUrlProvider.serviceUrls) == 0 {
+		return serviceUrlProvider.clientProperties.GetEurekaServiceUrls(serviceUrlProvider.instanceZone)
+	}
+	return append([]string(nil), serviceUrlProvider.serviceUrls...)
+}
+
+func (serviceUrlProvider *DnsServiceUrlProvider) resolve(resolver TxtResolver, logger Logger) ([]string, error) {
+	dnsName := serviceUrlProvider.clientProperties.EurekaServerDnsName
+	if dnsName == "" {
+		return nil, errors.New("eureka: eureka server dns name is not configured")
+	}
+
+	// txt.<region>.<dns name> lists the zone records, txt.<zone record> lists the servers of the zone
+	zoneRecords, err := serviceUrlProvider.lookup(resolver, "txt."+serviceUrlProvider.clientProperties.Region+"."+dnsName)
+	if err != nil {
+		return nil, err
+	}
+
+	startIndex := 0
+	if serviceUrlProvider.clientProperties.PreferSameZoneEureka {
+		for index, zoneRecord := range zoneRecords {
+			if strings.SplitN(zoneRecord, ".", 2)[0] == serviceUrlProvider.instanceZone {
+				startIndex = index
+				break
+			}
+		}
+	}
+
+	serviceUrls := make([]string, 0)
+	for offset := 0; offset < len(zoneRecords); offset++ {
+		zoneRecord := zoneRecords[(startIndex+offset)%len(zoneRecords)]
+
+		hosts, err := serviceUrlProvider.lookup(resolver, "txt."+zoneRecord)
+		if err != nil {
+			// the servers of the other zones are still usable
+			logger.Warning("service urls of the zone could not be resolved from dns", Fields{
+				FieldRegion: serviceUrlProvider.clientProperties.Region,
+				FieldZone:   zoneRecord,
+			}.withError(err))
+			continue
+		}
+
+		for _, host := range hosts {
+			serviceUrls = append(serviceUrls, serviceUrlProvider.getServiceUrl(host))
+		}
+	}
+
+	if len(serviceUrls) == 0 {
+		return nil, errors.New("eureka: there is no service url in the dns records of " + dnsName)
+	}
+	return serviceUrls, nil
+}
+
+func (serviceUrlProvider *DnsServiceUrlProvider) lookup(resolver TxtResolver, name string) ([]string, error) {
+	records, err := resolver.LookupTXT(name)
+	if err != nil {
+		return nil, err
+	}
+
+	values := make([]string, 0)
+	for _, record := range records {
+		for _, value := range strings.Fields(record) {
+			values = append(values, strings.TrimSuffix(value, "."))
+		}
+	}
+	return values, nil
+}
+
+func (serviceUrlProvider *DnsServiceUrlProvider) getServiceUrl(host string) string {
+	serviceUrl := "http://" + host
+	if port := serviceUrlProvider.clientProperties.EurekaServerPort; port > 0 {
+		serviceUrl += ":" + strconv.Itoa(port)
+	}
+	serviceUrl += "/"
+	if urlContext := strings.Trim(serviceUrlProvider.clientProperties.EurekaServerUrlContext, "/"); urlContext != "" {
+		serviceUrl += urlContext + "/"
+	}
+	return serviceUrl
+}
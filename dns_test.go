@@ -0,0 +1,161 @@
+package eureka
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type fakeTxtResolver map[string][]string
+
+func (resolver fakeTxtResolver) LookupTXT(name string) ([]string, error) {
+	records, ok := resolver[name]
+	if !ok {
+		return nil, errors.New("no such host: " + name)
+	}
+	return records, nil
+}
+
+func TestDnsServiceUrlProvider_Refresh(t *testing.T) {
+	resolver := fakeTxtResolver{
+		"txt.us-east-1.eureka.example.com":   {"us-east-1a.eureka.example.com. us-east-1b.eureka.example.com."},
+		"txt.us-east-1a.eureka.example.com":  {"server-a1.example.com server-a2.example.com"},
+		"txt.us-east-1b.eureka.example.com":  {"server-b1.example.com"},
+		"txt.eu-west-1.eureka.example.com":   {"eu-west-1a.eureka.example.com"},
+		"txt.eu-west-1a.eureka.example.com":  {},
+		"txt.ap-south-1.eureka.example.com":  {"ap-south-1a.eureka.example.com ap-south-1b.eureka.example.com"},
+		"txt.ap-south-1b.eureka.example.com": {"server-b1.example.com"},
+		"txt.sa-east-1.eureka.example.com":   {"sa-east-1a.eureka.example.com"},
+	}
+
+	testCases := []struct {
+		name             string
+		clientProperties ClientProperties
+		instanceZone     string
+		expected         []string
+		expectedWarning  string
+		expectErr        bool
+	}{
+		{
+			name: "zones in the dns order",
+			clientProperties: ClientProperties{
+				Region:              "us-east-1",
+				EurekaServerDnsName: "eureka.example.com",
+			},
+			instanceZone: "us-east-1b",
+			expected: []string{
+				"http://server-a1.example.com/",
+				"http://server-a2.example.com/",
+				"http://server-b1.example.com/",
+			},
+		},
+		{
+			name: "same zone first with port and context",
+			clientProperties: ClientProperties{
+				Region:                 "us-east-1",
+				EurekaServerDnsName:    "eureka.example.com",
+				EurekaServerPort:       8761,
+				EurekaServerUrlContext: "/eureka/",
+				PreferSameZoneEureka:   true,
+			},
+			instanceZone: "us-east-1b",
+			expected: []string{
+				"http://server-b1.example.com:8761/eureka/",
+				"http://server-a1.example.com:8761/eureka/",
+				"http://server-a2.example.com:8761/eureka/",
+			},
+		},
+		{
+			name: "no server in the records",
+			clientProperties: ClientProperties{
+				Region:              "eu-west-1",
+				EurekaServerDnsName: "eureka.example.com",
+			},
+			expectErr: true,
+		},
+		{
+			name: "unresolvable zone record is skipped",
+			clientProperties: ClientProperties{
+				Region:              "ap-south-1",
+				EurekaServerDnsName: "eureka.example.com",
+			},
+			expected: []string{
+				"http://server-b1.example.com/",
+			},
+			expectedWarning: "WARNING service urls of the zone could not be resolved from dns",
+		},
+		{
+			name: "no resolvable zone record",
+			clientProperties: ClientProperties{
+				Region:              "sa-east-1",
+				EurekaServerDnsName: "eureka.example.com",
+			},
+			expectedWarning: "WARNING service urls of the zone could not be resolved from dns",
+			expectErr:       true,
+		},
+		{
+			name: "no dns name",
+			clientProperties: ClientProperties{
+				Region: "us-east-1",
+			},
+			expectErr: true,
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			serviceUrlProvider := NewDnsServiceUrlProvider(testCase.clientProperties, testCase.instanceZone)
+			serviceUrlProvider.SetTxtResolver(resolver)
+			recorder := &contextLoggerRecorder{}
+			serviceUrlProvider.SetLogger(newContextLogger(recorder))
+
+			err := serviceUrlProvider.Refresh()
+			if (err != nil) != testCase.expectErr {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if testCase.expectedWarning != "" {
+				logged := false
+				for _, entry := range recorder.getEntries() {
+					logged = logged || strings.HasPrefix(entry, testCase.expectedWarning)
+				}
+				if !logged {
+					t.Errorf("expected %q in the log entries: %v", testCase.expectedWarning, recorder.getEntries())
+				}
+			}
+			if testCase.expectErr {
+				return
+			}
+			if serviceUrls := serviceUrlProvider.GetServiceUrls(); !reflect.DeepEqual(serviceUrls, testCase.expected) {
+				t.Errorf("expected %v, got %v", testCase.expected, serviceUrls)
+			}
+		})
+	}
+}
+
+func TestDnsServiceUrlProvider_KeepsLastUrls(t *testing.T) {
+	resolver := fakeTxtResolver{
+		"txt.us-east-1.eureka.example.com":  {"us-east-1a.eureka.example.com"},
+		"txt.us-east-1a.eureka.example.com": {"server-a1.example.com"},
+	}
+	serviceUrlProvider := NewDnsServiceUrlProvider(ClientProperties{
+		Region:              "us-east-1",
+		EurekaServerDnsName: "eureka.example.com",
+	}, "")
+	serviceUrlProvider.SetTxtResolver(resolver)
+
+	if err := serviceUrlProvider.Refresh(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// a failing lookup keeps the urls resolved before
+	delete(resolver, "txt.us-east-1a.eureka.example.com")
+	if err := serviceUrlProvider.Refresh(); err == nil {
+		t.Fatalf("expected an error")
+	}
+
+	expected := []string{"http://server-a1.example.com/"}
+	if serviceUrls := serviceUrlProvider.GetServiceUrls(); !reflect.DeepEqual(serviceUrls, expected) {
+		t.Errorf("expected %v, got %v", expected, serviceUrls)
+	}
+}
@@ -1,5 +1,12 @@
 package eureka
 
+import (
+	"encoding/xml"
+	"sort"
+	"strconv"
+	"strings"
+)
+
 type Applications struct {
 	VersionsDelta string        `json:"versions__delta" xml:"versions__delta"`
 	AppsHashcode  string        `json:"apps__hashcode" xml:"apps__hashcode"`
@@ -12,8 +19,8 @@ type Application struct {
 }
 
 type PortWrapper struct {
-	Enabled string `json:"@enabled" xml:",chardata"`
-	Port    int    `json:"$" xml:"enabled,attr"`
+	Enabled string `json:"@enabled" xml:"enabled,attr"`
+	Port    int    `json:"$" xml:",chardata"`
 }
 
 type DataCenterName string
@@ -24,9 +31,31 @@ const (
 	DataCenterMyOwn   DataCenterName = "MyOwn"
 )
 
+const (
+	DefaultDataCenterInfoClass = "com.netflix.appinfo.InstanceInfo$DefaultDataCenterInfo"
+	AmazonInfoClass            = "com.netflix.appinfo.AmazonInfo"
+)
+
 type DataCenterInfo struct {
-	Name  DataCenterName `json:"name" xml:"name"`
-	Class string         `json:"@class" xml:"class,attr"`
+	Name     DataCenterName `json:"name" xml:"name"`
+	Class    string         `json:"@class" xml:"class,attr"`
+	Metadata *AmazonInfo    `json:"metadata,omitempty" xml:"metadata,omitempty"`
+}
+
+type AmazonInfo struct {
+	InstanceId       string `json:"instance-id,omitempty" xml:"instance-id,omitempty"`
+	AmiId            string `json:"ami-id,omitempty" xml:"ami-id,omitempty"`
+	AmiLaunchIndex   string `json:"ami-launch-index,omitempty" xml:"ami-launch-index,omitempty"`
+	AmiManifestPath  string `json:"ami-manifest-path,omitempty" xml:"ami-manifest-path,omitempty"`
+	InstanceType     string `json:"instance-type,omitempty" xml:"instance-type,omitempty"`
+	AvailabilityZone string `json:"availability-zone,omitempty" xml:"availability-zone,omitempty"`
+	PublicHostname   string `json:"public-hostname,omitempty" xml:"public-hostname,omitempty"`
+	PublicIpv4       string `json:"public-ipv4,omitempty" xml:"public-ipv4,omitempty"`
+	LocalHostname    string `json:"local-hostname,omitempty" xml:"local-hostname,omitempty"`
+	LocalIpv4        string `json:"local-ipv4,omitempty" xml:"local-ipv4,omitempty"`
+	Mac              string `json:"mac,omitempty" xml:"mac,omitempty"`
+	VpcId            string `json:"vpc-id,omitempty" xml:"vpc-id,omitempty"`
+	AccountId        string `json:"accountId,omitempty" xml:"accountId,omitempty"`
 }
 
 type InstanceStatus string
@@ -42,6 +71,48 @@ const (
 type Metadata struct {
 }
 
+type MetadataMap map[string]string
+
+func (metadata MetadataMap) MarshalXML(encoder *xml.Encoder, start xml.StartElement) error {
+	keys := make([]string, 0, len(metadata))
+	for key := range metadata {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	if err := encoder.EncodeToken(start); err != nil {
+		return err
+	}
+	for _, key := range keys {
+		if err := encoder.EncodeElement(metadata[key], xml.StartElement{Name: xml.Name{Local: key}}); err != nil {
+			return err
+		}
+	}
+	return encoder.EncodeToken(start.End())
+}
+
+func (metadata *MetadataMap) UnmarshalXML(decoder *xml.Decoder, start xml.StartElement) error {
+	values := make(MetadataMap)
+	for {
+		token, err := decoder.Token()
+		if err != nil {
+			return err
+		}
+
+		switch element := token.(type) {
+		case xml.StartElement:
+			var value string
+			if err = decoder.DecodeElement(&value, &element); err != nil {
+				return err
+			}
+			values[element.Name.Local] = value
+		case xml.EndElement:
+			*metadata = values
+			return nil
+		}
+	}
+}
+
 type LeaseInfo struct {
 	RenewalIntervalInSecs      int `json:"renewalIntervalInSecs,omitempty" xml:"renewalIntervalInSecs,omitempty"`
 	DurationInSecs             int `json:"durationInSecs,omitempty" xml:"durationInSecs,omitempty"`
@@ -61,28 +132,180 @@ const (
 )
 
 type InstanceInfo struct {
-	InstanceId                    string            `json:"instanceId,omitempty" xml:"instanceId,omitempty"`
-	AppName                       string            `json:"app" xml:"app"`
-	AppGroupName                  string            `json:"appGroupName" xml:"appGroupName"`
-	IpAddr                        string            `json:"ipAddr" xml:"ipAddr"`
-	Port                          *PortWrapper      `json:"port" xml:"port"`
-	SecurePort                    *PortWrapper      `json:"securePort" xml:"securePort"`
-	HomePageUrl                   string            `json:"homePageUrl" xml:"homePageUrl"`
-	StatusPageUrl                 string            `json:"statusPageUrl" xml:"statusPageUrl"`
-	HealthCheckUrl                string            `json:"healthCheckUrl" xml:"healthCheckUrl"`
-	SecureHealthCheckUrl          string            `json:"secureHealthCheckUrl" xml:"secureHealthCheckUrl"`
-	VipAddress                    string            `json:"vipAddress" xml:"vipAddress"`
-	SecureVipAddress              string            `json:"secureVipAddress" xml:"secureVipAddress"`
-	CountryId                     int               `json:"countryId" xml:"countryId"`
-	DataCenterInfo                *DataCenterInfo   `json:"dataCenterInfo" xml:"dataCenterInfo"`
-	HostName                      string            `json:"hostName" xml:"hostName"`
-	Status                        InstanceStatus    `json:"status" xml:"status"`
-	OverriddenStatus              InstanceStatus    `json:"overriddenstatus" xml:"overriddenstatus"`
-	LeaseInfo                     *LeaseInfo        `json:"leaseInfo" xml:"leaseInfo"`
-	IsCoordinatingDiscoveryServer string            `json:"isCoordinatingDiscoveryServer" xml:"isCoordinatingDiscoveryServer"`
-	Metadata                      map[string]string `json:"metadata,omitempty" xml:"metadata,omitempty"`
-	LastUpdatedTimestamp          string            `json:"lastUpdatedTimestamp" xml:"lastUpdatedTimestamp"`
-	LastDirtyTimestamp            string            `json:"lastDirtyTimestamp" xml:"lastDirtyTimestamp"`
-	ActionType                    ActionType        `json:"actionType" xml:"actionType"`
-	AsgName                       string            `json:"asgName" xml:"asgName"`
+	InstanceId                    string          `json:"instanceId,omitempty" xml:"instanceId,omitempty"`
+	AppName                       string          `json:"app" xml:"app"`
+	AppGroupName                  string          `json:"appGroupName" xml:"appGroupName"`
+	IpAddr                        string          `json:"ipAddr" xml:"ipAddr"`
+	Port                          *PortWrapper    `json:"port" xml:"port"`
+	SecurePort                    *PortWrapper    `json:"securePort" xml:"securePort"`
+	HomePageUrl                   string          `json:"homePageUrl" xml:"homePageUrl"`
+	StatusPageUrl                 string          `json:"statusPageUrl" xml:"statusPageUrl"`
+	HealthCheckUrl                string          `json:"healthCheckUrl" xml:"healthCheckUrl"`
+	SecureHealthCheckUrl          string          `json:"secureHealthCheckUrl" xml:"secureHealthCheckUrl"`
+	VipAddress                    string          `json:"vipAddress" xml:"vipAddress"`
+	SecureVipAddress              string          `json:"secureVipAddress" xml:"secureVipAddress"`
+	CountryId                     int             `json:"countryId" xml:"countryId"`
+	DataCenterInfo                *DataCenterInfo `json:"dataCenterInfo" xml:"dataCenterInfo"`
+	HostName                      string          `json:"hostName" xml:"hostName"`
+	Status                        InstanceStatus  `json:"status" xml:"status"`
+	OverriddenStatus              InstanceStatus  `json:"overriddenstatus" xml:"overriddenstatus"`
+	LeaseInfo                     *LeaseInfo      `json:"leaseInfo" xml:"leaseInfo"`
+	IsCoordinatingDiscoveryServer string          `json:"isCoordinatingDiscoveryServer" xml:"isCoordinatingDiscoveryServer"`
+	Metadata                      MetadataMap     `json:"metadata,omitempty" xml:"metadata,omitempty"`
+	LastUpdatedTimestamp          string          `json:"lastUpdatedTimestamp" xml:"lastUpdatedTimestamp"`
+	LastDirtyTimestamp            string          `json:"lastDirtyTimestamp" xml:"lastDirtyTimestamp"`
+	ActionType                    ActionType      `json:"actionType" xml:"actionType"`
+	AsgName                       string          `json:"asgName" xml:"asgName"`
+}
+
+func (instanceInfo *InstanceInfo) GetZone() string {
+	if zone := instanceInfo.Metadata[ZoneKey]; zone != "" {
+		return zone
+	}
+	if instanceInfo.DataCenterInfo != nil && instanceInfo.DataCenterInfo.Metadata != nil {
+		return instanceInfo.DataCenterInfo.Metadata.AvailabilityZone
+	}
+	return ""
+}
+
+func (applications *Applications) GetApplication(name string) *Application {
+	if applications == nil {
+		return nil
+	}
+	for index := range applications.Applications {
+		if strings.EqualFold(applications.Applications[index].Name, name) {
+			return &applications.Applications[index]
+		}
+	}
+	return nil
+}
+
+func (applications *Applications) GetInstancesCount() int {
+	count := 0
+	if applications == nil {
+		return count
+	}
+	for _, application := range applications.Applications {
+		count += len(application.Instances)
+	}
+	return count
+}
+
+func (applications *Applications) ComputeHashcode() string {
+	statusCounts := make(map[string]int)
+	if applications != nil {
+		for _, application := range applications.Applications {
+			for _, instance := range application.Instances {
+				statusCounts[string(instance.Status)]++
+			}
+		}
+	}
+
+	statuses := make([]string, 0, len(statusCounts))
+	for status := range statusCounts {
+		statuses = append(statuses, status)
+	}
+	sort.Strings(statuses)
+
+	hashcode := ""
+	for _, status := range statuses {
+		hashcode += status + "_" + strconv.Itoa(statusCounts[status]) + "_"
+	}
+	return hashcode
+}
+
+func (instanceInfo *InstanceInfo) copy() *InstanceInfo {
+	copied := *instanceInfo
+	if instanceInfo.Port != nil {
+		port := *instanceInfo.Port
+		copied.Port = &port
+	}
+	if instanceInfo.SecurePort != nil {
+		securePort := *instanceInfo.SecurePort
+		copied.SecurePort = &securePort
+	}
+	if instanceInfo.DataCenterInfo != nil {
+		dataCenterInfo := *instanceInfo.DataCenterInfo
+		if dataCenterInfo.Metadata != nil {
+			amazonInfo := *dataCenterInfo.Metadata
+			dataCenterInfo.Metadata = &amazonInfo
+		}
+		copied.DataCenterInfo = &dataCenterInfo
+	}
+	if instanceInfo.LeaseInfo != nil {
+		leaseInfo := *instanceInfo.LeaseInfo
+		copied.LeaseInfo = &leaseInfo
+	}
+	if instanceInfo.Metadata != nil {
+		copied.Metadata = make(MetadataMap, len(instanceInfo.Metadata))
+		for key, value := range instanceInfo.Metadata {
+			copied.Metadata[key] = value
+		}
+	}
+	return &copied
+}
+
+func (applications *Applications) copy() *Applications {
+	if applications == nil {
+		return &Applications{}
+	}
+	copied := &Applications{
+		VersionsDelta: applications.VersionsDelta,
+		AppsHashcode:  applications.AppsHashcode,
+		Applications:  make([]Application, len(applications.Applications)),
+	}
+	for index, application := range applications.Applications {
+		copied.Applications[index] = Application{
+			Name:      application.Name,
+			Instances: append([]InstanceInfo(nil), application.Instances...),
+		}
+	}
+	return copied
+}
+
+func (applications *Applications) applyDelta(delta *Applications) {
+	for _, deltaApplication := range delta.Applications {
+		for _, deltaInstance := range deltaApplication.Instances {
+			application := applications.GetApplication(deltaApplication.Name)
+			if application == nil {
+				if deltaInstance.ActionType == ActionDeleted {
+					continue
+				}
+				applications.Applications = append(applications.Applications, Application{Name: deltaApplication.Name})
+				application = &applications.Applications[len(applications.Applications)-1]
+			}
+
+			index := application.indexOf(deltaInstance.InstanceId)
+			switch deltaInstance.ActionType {
+			case ActionDeleted:
+				if index != -1 {
+					application.Instances = append(application.Instances[:index], application.Instances[index+1:]...)
+				}
+			default:
+				if index != -1 {
+					application.Instances[index] = deltaInstance
+				} else {
+					application.Instances = append(application.Instances, deltaInstance)
+				}
+			}
+		}
+	}
+
+	remaining := applications.Applications[:0]
+	for _, application := range applications.Applications {
+		if len(application.Instances) != 0 {
+			remaining = append(remaining, application)
+		}
+	}
+	applications.Applications = remaining
+	applications.VersionsDelta = delta.VersionsDelta
+}
+
+func (application *Application) indexOf(instanceId string) int {
+	for index, instance := range application.Instances {
+		if instance.InstanceId == instanceId {
+			return index
+		}
+	}
+	return -1
 }
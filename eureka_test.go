@@ -0,0 +1,136 @@
+package eureka
+
+import (
+	"reflect"
+	"testing"
+)
+
+func newTestInstance(appName, instanceId string, status InstanceStatus, actionType ActionType) InstanceInfo {
+	return InstanceInfo{
+		InstanceId: instanceId,
+		AppName:    appName,
+		Status:     status,
+		ActionType: actionType,
+	}
+}
+
+func withMetadata(instance InstanceInfo, metadata MetadataMap) InstanceInfo {
+	instance.Metadata = metadata
+	return instance
+}
+
+func TestApplications_ComputeHashcode(t *testing.T) {
+	testCases := []struct {
+		name         string
+		applications *Applications
+		expected     string
+	}{
+		{
+			name:     "nil applications",
+			expected: "",
+		},
+		{
+			name:         "no instance",
+			applications: &Applications{},
+			expected:     "",
+		},
+		{
+			name: "statuses in alphabetical order",
+			applications: &Applications{
+				Applications: []Application{
+					{Name: "A", Instances: []InstanceInfo{
+						newTestInstance("A", "a1", InstanceStatusUp, ""),
+						newTestInstance("A", "a2", InstanceStatusDown, ""),
+					}},
+					{Name: "B", Instances: []InstanceInfo{
+						newTestInstance("B", "b1", InstanceStatusUp, ""),
+					}},
+				},
+			},
+			expected: "DOWN_1_UP_2_",
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			if hashcode := testCase.applications.ComputeHashcode(); hashcode != testCase.expected {
+				t.Errorf("expected %q, got %q", testCase.expected, hashcode)
+			}
+		})
+	}
+}
+
+func TestApplications_ApplyDelta(t *testing.T) {
+	testCases := []struct {
+		name     string
+		local    []Application
+		delta    []Application
+		expected []Application
+	}{
+		{
+			name: "added instance of a new application",
+			local: []Application{
+				{Name: "A", Instances: []InstanceInfo{newTestInstance("A", "a1", InstanceStatusUp, "")}},
+			},
+			delta: []Application{
+				{Name: "B", Instances: []InstanceInfo{newTestInstance("B", "b1", InstanceStatusUp, ActionAdded)}},
+			},
+			expected: []Application{
+				{Name: "A", Instances: []InstanceInfo{newTestInstance("A", "a1", InstanceStatusUp, "")}},
+				{Name: "B", Instances: []InstanceInfo{newTestInstance("B", "b1", InstanceStatusUp, ActionAdded)}},
+			},
+		},
+		{
+			name: "modified instance is replaced",
+			local: []Application{
+				{Name: "A", Instances: []InstanceInfo{newTestInstance("A", "a1", InstanceStatusUp, "")}},
+			},
+			delta: []Application{
+				{Name: "A", Instances: []InstanceInfo{newTestInstance("A", "a1", InstanceStatusDown, ActionModified)}},
+			},
+			expected: []Application{
+				{Name: "A", Instances: []InstanceInfo{newTestInstance("A", "a1", InstanceStatusDown, ActionModified)}},
+			},
+		},
+		{
+			name: "deleting the last instance removes the application",
+			local: []Application{
+				{Name: "A", Instances: []InstanceInfo{newTestInstance("A", "a1", InstanceStatusUp, "")}},
+				{Name: "B", Instances: []InstanceInfo{newTestInstance("B", "b1", InstanceStatusUp, "")}},
+			},
+			delta: []Application{
+				{Name: "A", Instances: []InstanceInfo{newTestInstance("A", "a1", InstanceStatusUp, ActionDeleted)}},
+			},
+			expected: []Application{
+				{Name: "B", Instances: []InstanceInfo{newTestInstance("B", "b1", InstanceStatusUp, "")}},
+			},
+		},
+		{
+			name: "deleting an unknown instance is ignored",
+			local: []Application{
+				{Name: "A", Instances: []InstanceInfo{newTestInstance("A", "a1", InstanceStatusUp, "")}},
+			},
+			delta: []Application{
+				{Name: "A", Instances: []InstanceInfo{newTestInstance("A", "a2", InstanceStatusUp, ActionDeleted)}},
+				{Name: "C", Instances: []InstanceInfo{newTestInstance("C", "c1", InstanceStatusUp, ActionDeleted)}},
+			},
+			expected: []Application{
+				{Name: "A", Instances: []InstanceInfo{newTestInstance("A", "a1", InstanceStatusUp, "")}},
+			},
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			applications := &Applications{
+				Applications: testCase.local,
+			}
+			applications.applyDelta(&Applications{
+				Applications: testCase.delta,
+			})
+			if !reflect.DeepEqual(applications.Applications, testCase.expected) {
+				t.Errorf("expected %+v, got %+v", testCase.expected, applications.Applications)
+			}
+		})
+	}
+}
@@ -0,0 +1,39 @@
+package eurekatest
+
+import (
+	"sync"
+	"time"
+)
+
+type Clock interface {
+	Now() time.Time
+}
+
+type ManualClock struct {
+	now   time.Time
+	nowMu sync.RWMutex
+}
+
+func NewManualClock(now time.Time) *ManualClock {
+	return &ManualClock{
+		now: now,
+	}
+}
+
+func (clock *ManualClock) Now() time.Time {
+	clock.nowMu.RLock()
+	defer clock.nowMu.RUnlock()
+	return clock.now
+}
+
+func (clock *ManualClock) Set(now time.Time) {
+	clock.nowMu.Lock()
+	defer clock.nowMu.Unlock()
+	clock.now = now
+}
+
+func (clock *ManualClock) Advance(duration time.Duration) {
+	clock.nowMu.Lock()
+	defer clock.nowMu.Unlock()
+	clock.now = clock.now.Add(duration)
+}
@@ -0,0 +1,501 @@
+package eurekatest
+
+import (
+	"encoding/json"
+	eureka "github.com/procyon-projects/procyon-cloud-eureka-client"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	servicePrefix        = "/eureka/"
+	defaultLeaseDuration = 90 * time.Second
+	deltaRetention       = 3 * time.Minute
+)
+
+type lease struct {
+	instance    eureka.InstanceInfo
+	lastRenewal time.Time
+	duration    time.Duration
+}
+
+type change struct {
+	instance  eureka.InstanceInfo
+	changedAt time.Time
+}
+
+type Server struct {
+	httpServer    *httptest.Server
+	clock         Clock
+	leases        map[string]map[string]*lease
+	changes       []change
+	version       int
+	latency       time.Duration
+	failureStatus int
+	failureCount  int
+	requests      []string
+	registryMu    sync.Mutex
+}
+
+func NewServer() *Server {
+	return NewServerWithClock(NewManualClock(time.Now()))
+}
+
+func NewServerWithClock(clock Clock) *Server {
+	server := &Server{
+		clock:  clock,
+		leases: make(map[string]map[string]*lease),
+	}
+	server.httpServer = httptest.NewServer(http.HandlerFunc(server.serveHTTP))
+	return server
+}
+
+func (server *Server) URL() string {
+	return server.httpServer.URL + servicePrefix
+}
+
+func (server *Server) Clock() Clock {
+	return server.clock
+}
+
+func (server *Server) Close() {
+	server.httpServer.Close()
+}
+
+func (server *Server) SetLatency(latency time.Duration) {
+	server.registryMu.Lock()
+	defer server.registryMu.Unlock()
+	server.latency = latency
+}
+
+// FailNext makes the next count requests fail with the given status code,
+// a negative count keeps failing until ClearFailures is called and a zero
+// count clears the failures.
+func (server *Server) FailNext(statusCode int, count int) {
+	server.registryMu.Lock()
+	defer server.registryMu.Unlock()
+	if count == 0 {
+		statusCode = 0
+	}
+	server.failureStatus = statusCode
+	server.failureCount = count
+}
+
+// GetRequests returns the requests received so far as "METHOD path?query", failed ones included.
+func (server *Server) GetRequests() []string {
+	server.registryMu.Lock()
+	defer server.registryMu.Unlock()
+	return append([]string(nil), server.requests...)
+}
+
+func (server *Server) ClearFailures() {
+	server.FailNext(0, 0)
+}
+
+// DropInstance removes the instance without recording a change, just like
+// a server losing its state would do.
+func (server *Server) DropInstance(appName, instanceId string) bool {
+	server.registryMu.Lock()
+	defer server.registryMu.Unlock()
+	instances, ok := server.leases[strings.ToUpper(appName)]
+	if !ok {
+		return false
+	}
+	if _, ok = instances[instanceId]; !ok {
+		return false
+	}
+	delete(instances, instanceId)
+	server.version++
+	return true
+}
+
+func (server *Server) GetInstance(appName, instanceId string) (eureka.InstanceInfo, bool) {
+	server.registryMu.Lock()
+	defer server.registryMu.Unlock()
+	server.evictExpiredLeases()
+	instanceLease, ok := server.leases[strings.ToUpper(appName)][instanceId]
+	if !ok {
+		return eureka.InstanceInfo{}, false
+	}
+	return copyInstance(instanceLease.instance), true
+}
+
+func (server *Server) GetApplications() *eureka.Applications {
+	server.registryMu.Lock()
+	defer server.registryMu.Unlock()
+	server.evictExpiredLeases()
+	return server.getApplications(func(instance eureka.InstanceInfo) bool {
+		return true
+	})
+}
+
+func (server *Server) serveHTTP(writer http.ResponseWriter, request *http.Request) {
+	server.registryMu.Lock()
+	server.requests = append(server.requests, request.Method+" "+request.URL.RequestURI())
+	latency := server.latency
+	failureStatus := server.failureStatus
+	if server.failureCount > 0 {
+		server.failureCount--
+		if server.failureCount == 0 {
+			server.failureStatus = 0
+		}
+	}
+	server.registryMu.Unlock()
+
+	if latency > 0 {
+		time.Sleep(latency)
+	}
+
+	if failureStatus != 0 {
+		writer.WriteHeader(failureStatus)
+		return
+	}
+
+	if !strings.HasPrefix(request.URL.Path, servicePrefix) {
+		writer.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	server.registryMu.Lock()
+	defer server.registryMu.Unlock()
+	server.evictExpiredLeases()
+
+	parts := strings.Split(strings.Trim(strings.TrimPrefix(request.URL.Path, servicePrefix), "/"), "/")
+	switch {
+	case parts[0] == "apps" && len(parts) == 1 && request.Method == http.MethodGet:
+		server.writeApplications(writer, server.getApplications(func(instance eureka.InstanceInfo) bool {
+			return true
+		}))
+	case parts[0] == "apps" && len(parts) == 2 && parts[1] == "delta" && request.Method == http.MethodGet:
+		server.writeApplications(writer, server.getDelta())
+	case parts[0] == "apps" && len(parts) == 2 && request.Method == http.MethodGet:
+		server.getApplication(writer, parts[1])
+	case parts[0] == "apps" && len(parts) == 2 && request.Method == http.MethodPost:
+		server.register(writer, request, parts[1])
+	case parts[0] == "apps" && len(parts) == 3 && request.Method == http.MethodGet:
+		server.getInstance(writer, parts[1], parts[2])
+	case parts[0] == "apps" && len(parts) == 3 && request.Method == http.MethodPut:
+		server.renew(writer, request, parts[1], parts[2])
+	case parts[0] == "apps" && len(parts) == 3 && request.Method == http.MethodDelete:
+		server.cancel(writer, parts[1], parts[2])
+	case parts[0] == "apps" && len(parts) == 4 && parts[3] == "status" && request.Method == http.MethodPut:
+		server.updateStatus(writer, parts[1], parts[2], eureka.InstanceStatus(request.URL.Query().Get("value")))
+	case parts[0] == "apps" && len(parts) == 4 && parts[3] == "status" && request.Method == http.MethodDelete:
+		server.updateStatus(writer, parts[1], parts[2], eureka.InstanceStatusUnknown)
+	case parts[0] == "apps" && len(parts) == 4 && parts[3] == "metadata" && request.Method == http.MethodPut:
+		server.updateMetadata(writer, request, parts[1], parts[2])
+	case parts[0] == "instances" && len(parts) == 2 && request.Method == http.MethodGet:
+		server.getInstanceById(writer, parts[1])
+	case parts[0] == "vips" && len(parts) == 2 && request.Method == http.MethodGet:
+		server.writeApplications(writer, server.getApplications(func(instance eureka.InstanceInfo) bool {
+			return instance.VipAddress == parts[1]
+		}))
+	case parts[0] == "svips" && len(parts) == 2 && request.Method == http.MethodGet:
+		server.writeApplications(writer, server.getApplications(func(instance eureka.InstanceInfo) bool {
+			return instance.SecureVipAddress == parts[1]
+		}))
+	default:
+		writer.WriteHeader(http.StatusNotFound)
+	}
+}
+
+func (server *Server) register(writer http.ResponseWriter, request *http.Request, appName string) {
+	body, err := ioutil.ReadAll(request.Body)
+	if err != nil {
+		writer.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	instanceResource := &eureka.InstanceResource{}
+	if err = json.Unmarshal(body, instanceResource); err != nil || instanceResource.InstanceInfo == nil {
+		writer.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	instance := *instanceResource.InstanceInfo
+	appName = strings.ToUpper(appName)
+	instance.AppName = appName
+
+	duration := defaultLeaseDuration
+	if instance.LeaseInfo != nil && instance.LeaseInfo.DurationInSecs > 0 {
+		duration = time.Duration(instance.LeaseInfo.DurationInSecs) * time.Second
+	}
+
+	now := server.clock.Now()
+	leaseInfo := eureka.LeaseInfo{}
+	if instance.LeaseInfo != nil {
+		leaseInfo = *instance.LeaseInfo
+	}
+	leaseInfo.RegistrationTimestamp = int(now.UnixNano() / int64(time.Millisecond))
+	leaseInfo.LastRenewalTimestamp = leaseInfo.RegistrationTimestamp
+	instance.LeaseInfo = &leaseInfo
+	instance.LastUpdatedTimestamp = strconv.FormatInt(now.UnixNano()/int64(time.Millisecond), 10)
+
+	actionType := eureka.ActionAdded
+	if _, ok := server.leases[appName]; !ok {
+		server.leases[appName] = make(map[string]*lease)
+	}
+	if _, ok := server.leases[appName][instance.InstanceId]; ok {
+		actionType = eureka.ActionModified
+	}
+
+	server.leases[appName][instance.InstanceId] = &lease{
+		instance:    instance,
+		lastRenewal: now,
+		duration:    duration,
+	}
+	server.recordChange(instance, actionType)
+	writer.WriteHeader(http.StatusNoContent)
+}
+
+func (server *Server) renew(writer http.ResponseWriter, request *http.Request, appName, instanceId string) {
+	instanceLease, ok := server.leases[strings.ToUpper(appName)][instanceId]
+	if !ok {
+		writer.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	now := server.clock.Now()
+	instanceLease.lastRenewal = now
+	// replaced rather than changed, the copies handed out before keep their own lease info
+	if instanceLease.instance.LeaseInfo != nil {
+		leaseInfo := *instanceLease.instance.LeaseInfo
+		leaseInfo.LastRenewalTimestamp = int(now.UnixNano() / int64(time.Millisecond))
+		instanceLease.instance.LeaseInfo = &leaseInfo
+	}
+
+	// the server's copy is older than the client's one, the client has to register again
+	lastDirtyTimestamp := request.URL.Query().Get("lastDirtyTimestamp")
+	if lastDirtyTimestamp != "" && server.isNewer(lastDirtyTimestamp, instanceLease.instance.LastDirtyTimestamp) {
+		writer.WriteHeader(http.StatusNotFound)
+		return
+	}
+	writer.WriteHeader(http.StatusOK)
+}
+
+func (server *Server) cancel(writer http.ResponseWriter, appName, instanceId string) {
+	appName = strings.ToUpper(appName)
+	instanceLease, ok := server.leases[appName][instanceId]
+	if !ok {
+		writer.WriteHeader(http.StatusNotFound)
+		return
+	}
+	delete(server.leases[appName], instanceId)
+	server.recordChange(instanceLease.instance, eureka.ActionDeleted)
+	writer.WriteHeader(http.StatusOK)
+}
+
+func (server *Server) updateStatus(writer http.ResponseWriter, appName, instanceId string, status eureka.InstanceStatus) {
+	instanceLease, ok := server.leases[strings.ToUpper(appName)][instanceId]
+	if !ok {
+		writer.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	instanceLease.instance.OverriddenStatus = status
+	if status != eureka.InstanceStatusUnknown {
+		instanceLease.instance.Status = status
+	}
+	server.recordChange(instanceLease.instance, eureka.ActionModified)
+	writer.WriteHeader(http.StatusOK)
+}
+
+func (server *Server) updateMetadata(writer http.ResponseWriter, request *http.Request, appName, instanceId string) {
+	instanceLease, ok := server.leases[strings.ToUpper(appName)][instanceId]
+	if !ok {
+		writer.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	metadata := make(map[string]string)
+	for key, value := range instanceLease.instance.Metadata {
+		metadata[key] = value
+	}
+	for key := range request.URL.Query() {
+		metadata[key] = request.URL.Query().Get(key)
+	}
+	instanceLease.instance.Metadata = metadata
+	server.recordChange(instanceLease.instance, eureka.ActionModified)
+	writer.WriteHeader(http.StatusOK)
+}
+
+func (server *Server) getApplication(writer http.ResponseWriter, appName string) {
+	application := server.getApplications(func(instance eureka.InstanceInfo) bool {
+		return true
+	}).GetApplication(appName)
+
+	if application == nil {
+		writer.WriteHeader(http.StatusNotFound)
+		return
+	}
+	server.writeJson(writer, &eureka.ApplicationResource{
+		Application: application,
+	})
+}
+
+func (server *Server) getInstance(writer http.ResponseWriter, appName, instanceId string) {
+	instanceLease, ok := server.leases[strings.ToUpper(appName)][instanceId]
+	if !ok {
+		writer.WriteHeader(http.StatusNotFound)
+		return
+	}
+	instance := instanceLease.instance
+	server.writeJson(writer, &eureka.InstanceResource{
+		InstanceInfo: &instance,
+	})
+}
+
+func (server *Server) getInstanceById(writer http.ResponseWriter, instanceId string) {
+	for _, instances := range server.leases {
+		if instanceLease, ok := instances[instanceId]; ok {
+			instance := instanceLease.instance
+			server.writeJson(writer, &eureka.InstanceResource{
+				InstanceInfo: &instance,
+			})
+			return
+		}
+	}
+	writer.WriteHeader(http.StatusNotFound)
+}
+
+func (server *Server) getApplications(filter func(instance eureka.InstanceInfo) bool) *eureka.Applications {
+	appNames := make([]string, 0, len(server.leases))
+	for appName := range server.leases {
+		appNames = append(appNames, appName)
+	}
+	sort.Strings(appNames)
+
+	applications := &eureka.Applications{
+		VersionsDelta: strconv.Itoa(server.version),
+		Applications:  make([]eureka.Application, 0),
+	}
+
+	for _, appName := range appNames {
+		instanceIds := make([]string, 0, len(server.leases[appName]))
+		for instanceId := range server.leases[appName] {
+			instanceIds = append(instanceIds, instanceId)
+		}
+		sort.Strings(instanceIds)
+
+		application := eureka.Application{
+			Name:      appName,
+			Instances: make([]eureka.InstanceInfo, 0),
+		}
+		for _, instanceId := range instanceIds {
+			instance := server.leases[appName][instanceId].instance
+			if filter(instance) {
+				application.Instances = append(application.Instances, copyInstance(instance))
+			}
+		}
+
+		if len(application.Instances) != 0 {
+			applications.Applications = append(applications.Applications, application)
+		}
+	}
+
+	applications.AppsHashcode = applications.ComputeHashcode()
+	return applications
+}
+
+func (server *Server) getDelta() *eureka.Applications {
+	delta := &eureka.Applications{
+		VersionsDelta: strconv.Itoa(server.version),
+		Applications:  make([]eureka.Application, 0),
+	}
+
+	for _, recentChange := range server.changes {
+		application := delta.GetApplication(recentChange.instance.AppName)
+		if application == nil {
+			delta.Applications = append(delta.Applications, eureka.Application{
+				Name: recentChange.instance.AppName,
+			})
+			application = &delta.Applications[len(delta.Applications)-1]
+		}
+		application.Instances = append(application.Instances, recentChange.instance)
+	}
+
+	// the hash code of the whole registry lets clients verify the merged result
+	delta.AppsHashcode = server.getApplications(func(instance eureka.InstanceInfo) bool {
+		return true
+	}).ComputeHashcode()
+	return delta
+}
+
+func (server *Server) recordChange(instance eureka.InstanceInfo, actionType eureka.ActionType) {
+	now := server.clock.Now()
+	instance.ActionType = actionType
+	instance.LastUpdatedTimestamp = strconv.FormatInt(now.UnixNano()/int64(time.Millisecond), 10)
+	server.changes = append(server.changes, change{
+		instance:  instance,
+		changedAt: now,
+	})
+	server.version++
+
+	recentChanges := server.changes[:0]
+	for _, recentChange := range server.changes {
+		if now.Sub(recentChange.changedAt) <= deltaRetention {
+			recentChanges = append(recentChanges, recentChange)
+		}
+	}
+	server.changes = recentChanges
+}
+
+func (server *Server) evictExpiredLeases() {
+	now := server.clock.Now()
+	for _, instances := range server.leases {
+		for instanceId, instanceLease := range instances {
+			if now.Sub(instanceLease.lastRenewal) > instanceLease.duration {
+				delete(instances, instanceId)
+				server.recordChange(instanceLease.instance, eureka.ActionDeleted)
+			}
+		}
+	}
+}
+
+func (server *Server) isNewer(timestamp, other string) bool {
+	first, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return false
+	}
+
+	var second int64
+	second, err = strconv.ParseInt(other, 10, 64)
+	if err != nil {
+		return false
+	}
+	return first > second
+}
+
+func (server *Server) writeApplications(writer http.ResponseWriter, applications *eureka.Applications) {
+	server.writeJson(writer, &eureka.ApplicationsResource{
+		Applications: applications,
+	})
+}
+
+func (server *Server) writeJson(writer http.ResponseWriter, body interface{}) {
+	writer.Header().Set("Content-Type", "application/json")
+	writer.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(writer).Encode(body)
+}
+
+// copyInstance keeps the callers from sharing the lease info and the metadata with the registry.
+func copyInstance(instance eureka.InstanceInfo) eureka.InstanceInfo {
+	if instance.LeaseInfo != nil {
+		leaseInfo := *instance.LeaseInfo
+		instance.LeaseInfo = &leaseInfo
+	}
+	if instance.Metadata != nil {
+		metadata := make(eureka.MetadataMap, len(instance.Metadata))
+		for key, value := range instance.Metadata {
+			metadata[key] = value
+		}
+		instance.Metadata = metadata
+	}
+	return instance
+}
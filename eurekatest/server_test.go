@@ -0,0 +1,370 @@
+package eurekatest
+
+import (
+	eureka "github.com/procyon-projects/procyon-cloud-eureka-client"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func newTestInstance(instanceId string) *eureka.InstanceInfo {
+	return &eureka.InstanceInfo{
+		InstanceId: instanceId,
+		AppName:    "TEST",
+		Status:     eureka.InstanceStatusUp,
+		// outlives the delta retention, the tests move the clock past it
+		LeaseInfo: &eureka.LeaseInfo{
+			DurationInSecs: 600,
+		},
+	}
+}
+
+func TestServer_FailNext(t *testing.T) {
+	testCases := []struct {
+		name       string
+		statusCode int
+		count      int
+		expected   []int
+	}{
+		{
+			name:       "zero count clears the failures",
+			statusCode: http.StatusServiceUnavailable,
+			count:      0,
+			expected:   []int{http.StatusOK, http.StatusOK},
+		},
+		{
+			name:       "positive count",
+			statusCode: http.StatusServiceUnavailable,
+			count:      2,
+			expected:   []int{http.StatusServiceUnavailable, http.StatusServiceUnavailable, http.StatusOK},
+		},
+		{
+			name:       "negative count",
+			statusCode: http.StatusInternalServerError,
+			count:      -1,
+			expected:   []int{http.StatusInternalServerError, http.StatusInternalServerError, http.StatusInternalServerError},
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			server := NewServer()
+			defer server.Close()
+			client := eureka.NewDefaultHttpClient(server.URL())
+
+			server.FailNext(testCase.statusCode, testCase.count)
+			for index, expected := range testCase.expected {
+				statusCode := http.StatusOK
+				if _, err := client.GetApplications(); err != nil {
+					responseError, ok := err.(*eureka.ResponseError)
+					if !ok {
+						t.Fatalf("unexpected error: %v", err)
+					}
+					statusCode = responseError.StatusCode
+				}
+				if statusCode != expected {
+					t.Errorf("request %d: expected %d, got %d", index, expected, statusCode)
+				}
+			}
+
+			server.ClearFailures()
+			if _, err := client.GetApplications(); err != nil {
+				t.Errorf("unexpected error after clearing the failures: %v", err)
+			}
+		})
+	}
+}
+
+func TestServer_Delta(t *testing.T) {
+	testCases := []struct {
+		name             string
+		change           func(client eureka.DefaultHttpClient) error
+		expectedAction   eureka.ActionType
+		expectedHashcode string
+	}{
+		{
+			name: "registered instance",
+			change: func(client eureka.DefaultHttpClient) error {
+				return client.Register(newTestInstance("second"))
+			},
+			expectedAction:   eureka.ActionAdded,
+			expectedHashcode: "UP_2_",
+		},
+		{
+			name: "status update",
+			change: func(client eureka.DefaultHttpClient) error {
+				return client.UpdateStatus("TEST", "first", eureka.InstanceStatusOutOfService, newTestInstance("first"))
+			},
+			expectedAction:   eureka.ActionModified,
+			expectedHashcode: "OUT_OF_SERVICE_1_",
+		},
+		{
+			name: "cancelled instance",
+			change: func(client eureka.DefaultHttpClient) error {
+				return client.Deregister("TEST", "first")
+			},
+			expectedAction:   eureka.ActionDeleted,
+			expectedHashcode: "",
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			server := NewServer()
+			defer server.Close()
+			client := eureka.NewDefaultHttpClient(server.URL())
+
+			if err := client.Register(newTestInstance("first")); err != nil {
+				t.Fatalf("instance could not be registered: %v", err)
+			}
+			// the changes older than the retention are not part of the delta anymore
+			server.Clock().(*ManualClock).Advance(deltaRetention + 1)
+
+			if err := testCase.change(client); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			delta, err := client.GetApplicationsDelta()
+			if err != nil {
+				t.Fatalf("delta could not be fetched: %v", err)
+			}
+			if delta.GetInstancesCount() != 1 || delta.Applications[0].Instances[0].ActionType != testCase.expectedAction {
+				t.Errorf("expected a single %s change, got %+v", testCase.expectedAction, delta.Applications)
+			}
+			if delta.AppsHashcode != testCase.expectedHashcode {
+				t.Errorf("expected hashcode %q, got %q", testCase.expectedHashcode, delta.AppsHashcode)
+			}
+		})
+	}
+}
+
+func TestServer_LeaseExpiry(t *testing.T) {
+	testCases := []struct {
+		name             string
+		renewAfter       time.Duration
+		checkAfter       time.Duration
+		expectRegistered bool
+	}{
+		{
+			name:             "lease within its duration",
+			checkAfter:       89 * time.Second,
+			expectRegistered: true,
+		},
+		{
+			name:             "renewed lease",
+			renewAfter:       60 * time.Second,
+			checkAfter:       60 * time.Second,
+			expectRegistered: true,
+		},
+		{
+			name:       "expired lease",
+			checkAfter: 91 * time.Second,
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			server := NewServer()
+			defer server.Close()
+			clock := server.Clock().(*ManualClock)
+			client := eureka.NewDefaultHttpClient(server.URL())
+
+			instance := newTestInstance("first")
+			instance.LeaseInfo.DurationInSecs = 90
+			if err := client.Register(instance); err != nil {
+				t.Fatalf("instance could not be registered: %v", err)
+			}
+
+			if testCase.renewAfter > 0 {
+				clock.Advance(testCase.renewAfter)
+				if err := client.SendHeartBeat("TEST", "first", instance, ""); err != nil {
+					t.Fatalf("instance could not be renewed: %v", err)
+				}
+			}
+			clock.Advance(testCase.checkAfter)
+
+			if _, registered := server.GetInstance("TEST", "first"); registered != testCase.expectRegistered {
+				t.Fatalf("expected the instance to be registered: %v", testCase.expectRegistered)
+			}
+			if testCase.expectRegistered {
+				return
+			}
+
+			// the evicted instance is reported as deleted and has to register again
+			delta, err := client.GetApplicationsDelta()
+			if err != nil {
+				t.Fatalf("delta could not be fetched: %v", err)
+			}
+			if delta.GetInstancesCount() != 2 || delta.Applications[0].Instances[1].ActionType != eureka.ActionDeleted {
+				t.Errorf("expected the eviction in the delta, got %+v", delta.Applications)
+			}
+			err = client.SendHeartBeat("TEST", "first", instance, "")
+			if responseError, ok := err.(*eureka.ResponseError); !ok || responseError.StatusCode != http.StatusNotFound {
+				t.Errorf("expected the renewal to be rejected with %d, got %v", http.StatusNotFound, err)
+			}
+		})
+	}
+}
+
+func TestServer_RenewalKeepsCopies(t *testing.T) {
+	server := NewServer()
+	defer server.Close()
+	client := eureka.NewDefaultHttpClient(server.URL())
+
+	instance := newTestInstance("first")
+	if err := client.Register(instance); err != nil {
+		t.Fatalf("instance could not be registered: %v", err)
+	}
+
+	copies := []eureka.InstanceInfo{
+		server.GetApplications().Applications[0].Instances[0],
+	}
+	if copied, ok := server.GetInstance("TEST", "first"); ok {
+		copies = append(copies, copied)
+	}
+
+	server.Clock().(*ManualClock).Advance(time.Minute)
+	if err := client.SendHeartBeat("TEST", "first", instance, ""); err != nil {
+		t.Fatalf("instance could not be renewed: %v", err)
+	}
+
+	renewed, _ := server.GetInstance("TEST", "first")
+	for _, copied := range copies {
+		if copied.LeaseInfo.LastRenewalTimestamp == renewed.LeaseInfo.LastRenewalTimestamp {
+			t.Errorf("expected the copy to keep its renewal timestamp %d", copied.LeaseInfo.LastRenewalTimestamp)
+		}
+	}
+}
+
+func TestServer_Operations(t *testing.T) {
+	testCases := []struct {
+		name            string
+		operation       func(client eureka.DefaultHttpClient) (*eureka.Applications, error)
+		expectedRequest string
+		expectedStatus  int
+		expectedIds     []string
+		check           func(instance eureka.InstanceInfo) bool
+	}{
+		{
+			name: "vip",
+			operation: func(client eureka.DefaultHttpClient) (*eureka.Applications, error) {
+				return client.GetVip("orders")
+			},
+			expectedRequest: "GET /eureka/vips/orders",
+			expectedIds:     []string{"first"},
+		},
+		{
+			name: "secure vip",
+			operation: func(client eureka.DefaultHttpClient) (*eureka.Applications, error) {
+				return client.GetSecureVip("secure-payments")
+			},
+			expectedRequest: "GET /eureka/svips/secure-payments",
+			expectedIds:     []string{"second"},
+		},
+		{
+			name: "unknown vip",
+			operation: func(client eureka.DefaultHttpClient) (*eureka.Applications, error) {
+				return client.GetVip("unknown")
+			},
+			expectedRequest: "GET /eureka/vips/unknown",
+		},
+		{
+			name: "metadata",
+			operation: func(client eureka.DefaultHttpClient) (*eureka.Applications, error) {
+				return nil, client.UpdateMetadata("TEST", "first", map[string]string{"lane": "canary"})
+			},
+			expectedRequest: "PUT /eureka/apps/TEST/first/metadata?lane=canary",
+			check: func(instance eureka.InstanceInfo) bool {
+				return instance.Metadata["lane"] == "canary" && instance.Metadata["zone"] == "a"
+			},
+		},
+		{
+			name: "metadata of an unknown instance",
+			operation: func(client eureka.DefaultHttpClient) (*eureka.Applications, error) {
+				return nil, client.UpdateMetadata("TEST", "unknown", map[string]string{"lane": "canary"})
+			},
+			expectedRequest: "PUT /eureka/apps/TEST/unknown/metadata?lane=canary",
+			expectedStatus:  http.StatusNotFound,
+		},
+		{
+			name: "status override",
+			operation: func(client eureka.DefaultHttpClient) (*eureka.Applications, error) {
+				instance := newTestInstance("first")
+				instance.LastDirtyTimestamp = "1700"
+				if err := client.UpdateStatus("TEST", "first", eureka.InstanceStatusOutOfService, instance); err != nil {
+					return nil, err
+				}
+				return nil, client.DeleteStatusOverride("TEST", "first", instance)
+			},
+			expectedRequest: "DELETE /eureka/apps/TEST/first/status?lastDirtyTimestamp=1700",
+			check: func(instance eureka.InstanceInfo) bool {
+				return instance.OverriddenStatus == eureka.InstanceStatusUnknown && instance.Status == eureka.InstanceStatusOutOfService
+			},
+		},
+		{
+			name: "status override of an unknown instance",
+			operation: func(client eureka.DefaultHttpClient) (*eureka.Applications, error) {
+				return nil, client.DeleteStatusOverride("TEST", "unknown", newTestInstance("unknown"))
+			},
+			expectedRequest: "DELETE /eureka/apps/TEST/unknown/status?lastDirtyTimestamp=",
+			expectedStatus:  http.StatusNotFound,
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			server := NewServer()
+			defer server.Close()
+			client := eureka.NewDefaultHttpClient(server.URL())
+
+			first := newTestInstance("first")
+			first.VipAddress = "orders"
+			first.Metadata = eureka.MetadataMap{"zone": "a"}
+			second := newTestInstance("second")
+			second.SecureVipAddress = "secure-payments"
+			for _, instance := range []*eureka.InstanceInfo{first, second} {
+				if err := client.Register(instance); err != nil {
+					t.Fatalf("instance could not be registered: %v", err)
+				}
+			}
+
+			applications, err := testCase.operation(client)
+			if testCase.expectedStatus != 0 {
+				if responseError, ok := err.(*eureka.ResponseError); !ok || responseError.StatusCode != testCase.expectedStatus {
+					t.Errorf("expected the status %d, got %v", testCase.expectedStatus, err)
+				}
+			} else if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			requests := server.GetRequests()
+			if request := requests[len(requests)-1]; request != testCase.expectedRequest {
+				t.Errorf("expected the request %q, got %q", testCase.expectedRequest, request)
+			}
+
+			if applications != nil {
+				instanceIds := make([]string, 0)
+				for _, application := range applications.Applications {
+					for _, instance := range application.Instances {
+						instanceIds = append(instanceIds, instance.InstanceId)
+					}
+				}
+				if len(instanceIds) != len(testCase.expectedIds) {
+					t.Fatalf("expected the instances %v, got %v", testCase.expectedIds, instanceIds)
+				}
+				for index := range instanceIds {
+					if instanceIds[index] != testCase.expectedIds[index] {
+						t.Errorf("expected the instances %v, got %v", testCase.expectedIds, instanceIds)
+					}
+				}
+			}
+
+			if testCase.check != nil {
+				instance, _ := server.GetInstance("TEST", "first")
+				if !testCase.check(instance) {
+					t.Errorf("unexpected instance after the operation: %+v", instance)
+				}
+			}
+		})
+	}
+}
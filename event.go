@@ -0,0 +1,60 @@
+package eureka
+
+import "time"
+
+type Event interface {
+	GetTimestamp() time.Time
+}
+
+type EventListener interface {
+	OnEvent(event Event)
+}
+
+func publishEvent(eventListeners []EventListener, event Event) {
+	for _, eventListener := range eventListeners {
+		eventListener.OnEvent(event)
+	}
+}
+
+type SelfPreservationStartedEvent struct {
+	Timestamp         time.Time
+	App               string
+	PreviousInstances int
+	FetchedInstances  int
+	HeldUntil         time.Time
+}
+
+func (event SelfPreservationStartedEvent) GetTimestamp() time.Time {
+	return event.Timestamp
+}
+
+type SelfPreservationEndedEvent struct {
+	Timestamp time.Time
+	App       string
+	Expired   bool
+}
+
+func (event SelfPreservationEndedEvent) GetTimestamp() time.Time {
+	return event.Timestamp
+}
+
+type StatusChangedEvent struct {
+	Timestamp      time.Time
+	PreviousStatus InstanceStatus
+	Status         InstanceStatus
+}
+
+func (event StatusChangedEvent) GetTimestamp() time.Time {
+	return event.Timestamp
+}
+
+type MetadataChangedEvent struct {
+	Timestamp time.Time
+	Key       string
+	Value     string
+	Removed   bool
+}
+
+func (event MetadataChangedEvent) GetTimestamp() time.Time {
+	return event.Timestamp
+}
@@ -0,0 +1,119 @@
+package eureka
+
+import (
+	"math/rand"
+	"time"
+)
+
+type InstanceFilter interface {
+	Accept(instance InstanceInfo) bool
+}
+
+type metadataInstanceFilter struct {
+	includeMetadata map[string]string
+	excludeMetadata map[string]string
+}
+
+func (filter metadataInstanceFilter) Accept(instance InstanceInfo) bool {
+	for key, value := range filter.includeMetadata {
+		if instanceValue, ok := instance.Metadata[key]; !ok || instanceValue != value {
+			return false
+		}
+	}
+
+	for key, value := range filter.excludeMetadata {
+		if instanceValue, ok := instance.Metadata[key]; ok && instanceValue == value {
+			return false
+		}
+	}
+	return true
+}
+
+type statusInstanceFilter struct {
+	status InstanceStatus
+}
+
+func (filter statusInstanceFilter) Accept(instance InstanceInfo) bool {
+	return instance.Status == filter.status
+}
+
+func newPropertyInstanceFilters(clientProperties ClientProperties) []InstanceFilter {
+	instanceFilters := make([]InstanceFilter, 0)
+	if clientProperties.FilterOnlyUpInstances {
+		instanceFilters = append(instanceFilters, statusInstanceFilter{InstanceStatusUp})
+	}
+
+	if len(clientProperties.IncludeMetadata) != 0 || len(clientProperties.ExcludeMetadata) != 0 {
+		instanceFilters = append(instanceFilters, metadataInstanceFilter{
+			includeMetadata: clientProperties.IncludeMetadata,
+			excludeMetadata: clientProperties.ExcludeMetadata,
+		})
+	}
+	return instanceFilters
+}
+
+func newShuffleRandom(seed int64) *rand.Rand {
+	if seed == 0 {
+		seed = time.Now().UnixNano()
+	}
+	return rand.New(rand.NewSource(seed))
+}
+
+func (cache *RegistryCache) AddInstanceFilter(instanceFilter InstanceFilter) {
+	cache.fetchMu.Lock()
+	defer cache.fetchMu.Unlock()
+	if instanceFilter != nil {
+		cache.instanceFilters = append(cache.instanceFilters, instanceFilter)
+	}
+}
+
+func (cache *RegistryCache) buildView(applications *Applications) *Applications {
+	if applications == nil {
+		return nil
+	}
+
+	view := &Applications{
+		VersionsDelta: applications.VersionsDelta,
+		Applications:  make([]Application, 0, len(applications.Applications)),
+	}
+
+	for _, application := range applications.Applications {
+		instances := make([]InstanceInfo, 0, len(application.Instances))
+		for _, instance := range application.Instances {
+			if cache.accept(instance) {
+				instances = append(instances, instance)
+			}
+		}
+
+		// the order differs from client to client, so they don't all pick the same instance first
+		if cache.clientProperties.ShuffleInstances {
+			cache.random.Shuffle(len(instances), func(i, j int) {
+				instances[i], instances[j] = instances[j], instances[i]
+			})
+		}
+
+		if len(instances) != 0 {
+			view.Applications = append(view.Applications, Application{
+				Name:      application.Name,
+				Instances: instances,
+			})
+		}
+	}
+	view.AppsHashcode = view.ComputeHashcode()
+	return view
+}
+
+func (cache *RegistryCache) accept(instance InstanceInfo) bool {
+	for _, instanceFilter := range cache.propertyFilters {
+		if !instanceFilter.Accept(instance) {
+			return false
+		}
+	}
+
+	for _, instanceFilter := range cache.instanceFilters {
+		if !instanceFilter.Accept(instance) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,148 @@
+package eureka
+
+import (
+	"reflect"
+	"testing"
+)
+
+type instanceIdFilter string
+
+func (filter instanceIdFilter) Accept(instance InstanceInfo) bool {
+	return instance.InstanceId != string(filter)
+}
+
+func TestRegistryCache_BuildView(t *testing.T) {
+	instances := []InstanceInfo{
+		withMetadata(newTestInstance("TEST", "up-canary", InstanceStatusUp, ""), map[string]string{"lane": "canary"}),
+		withMetadata(newTestInstance("TEST", "up-stable", InstanceStatusUp, ""), map[string]string{"lane": "stable"}),
+		withMetadata(newTestInstance("TEST", "down-stable", InstanceStatusDown, ""), map[string]string{"lane": "stable"}),
+		newTestInstance("TEST", "up-plain", InstanceStatusUp, ""),
+	}
+
+	testCases := []struct {
+		name             string
+		clientProperties ClientProperties
+		instanceFilters  []InstanceFilter
+		expected         []string
+	}{
+		{
+			name:     "no filter",
+			expected: []string{"up-canary", "up-stable", "down-stable", "up-plain"},
+		},
+		{
+			name: "only up instances",
+			clientProperties: ClientProperties{
+				FilterOnlyUpInstances: true,
+			},
+			expected: []string{"up-canary", "up-stable", "up-plain"},
+		},
+		{
+			name: "included metadata",
+			clientProperties: ClientProperties{
+				IncludeMetadata: map[string]string{"lane": "stable"},
+			},
+			expected: []string{"up-stable", "down-stable"},
+		},
+		{
+			name: "excluded metadata",
+			clientProperties: ClientProperties{
+				ExcludeMetadata: map[string]string{"lane": "canary"},
+			},
+			expected: []string{"up-stable", "down-stable", "up-plain"},
+		},
+		{
+			name: "property and custom filters together",
+			clientProperties: ClientProperties{
+				FilterOnlyUpInstances: true,
+				ExcludeMetadata:       map[string]string{"lane": "canary"},
+			},
+			instanceFilters: []InstanceFilter{instanceIdFilter("up-plain")},
+			expected:        []string{"up-stable"},
+		},
+		{
+			name: "nothing accepted",
+			clientProperties: ClientProperties{
+				IncludeMetadata: map[string]string{"lane": "unknown"},
+			},
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			cache := newRegistryCache(nil, testCase.clientProperties, nil, nil)
+			for _, instanceFilter := range testCase.instanceFilters {
+				cache.AddInstanceFilter(instanceFilter)
+			}
+
+			view := cache.buildView(&Applications{
+				Applications: []Application{
+					{Name: "TEST", Instances: instances},
+				},
+			})
+
+			var instanceIds []string
+			if application := view.GetApplication("TEST"); application != nil {
+				for _, instance := range application.Instances {
+					instanceIds = append(instanceIds, instance.InstanceId)
+				}
+			}
+			if !reflect.DeepEqual(instanceIds, testCase.expected) {
+				t.Errorf("expected %v, got %v", testCase.expected, instanceIds)
+			}
+			if view.AppsHashcode != view.ComputeHashcode() {
+				t.Errorf("expected the hashcode of the view, got %q", view.AppsHashcode)
+			}
+		})
+	}
+}
+
+func TestRegistryCache_BuildViewShuffle(t *testing.T) {
+	instances := make([]InstanceInfo, 0)
+	for _, instanceId := range []string{"a", "b", "c", "d", "e", "f", "g", "h"} {
+		instances = append(instances, newTestInstance("TEST", instanceId, InstanceStatusUp, ""))
+	}
+	applications := &Applications{
+		Applications: []Application{
+			{Name: "TEST", Instances: instances},
+		},
+	}
+
+	testCases := []struct {
+		name       string
+		firstSeed  int64
+		secondSeed int64
+		expectSame bool
+	}{
+		{
+			name:       "same seed gives the same order",
+			firstSeed:  42,
+			secondSeed: 42,
+			expectSame: true,
+		},
+		{
+			name:       "different seeds give different orders",
+			firstSeed:  42,
+			secondSeed: 7,
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			first := newRegistryCache(nil, ClientProperties{ShuffleInstances: true, ShuffleSeed: testCase.firstSeed}, nil, nil).buildView(applications)
+			second := newRegistryCache(nil, ClientProperties{ShuffleInstances: true, ShuffleSeed: testCase.secondSeed}, nil, nil).buildView(applications)
+
+			same := reflect.DeepEqual(first.Applications, second.Applications)
+			if same != testCase.expectSame {
+				t.Errorf("expected the same order to be %v, got %v and %v", testCase.expectSame, first.Applications, second.Applications)
+			}
+			if first.GetInstancesCount() != len(instances) {
+				t.Errorf("expected %d instances, got %d", len(instances), first.GetInstancesCount())
+			}
+		})
+	}
+
+	// the source registry is never shuffled in place
+	if applications.Applications[0].Instances[0].InstanceId != "a" {
+		t.Errorf("the fetched registry was modified")
+	}
+}
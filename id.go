@@ -0,0 +1,137 @@
+package eureka
+
+import (
+	"crypto/rand"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	InstanceIdStrategyHostAppPort = "hostAppPort"
+	InstanceIdStrategyRandom      = "random"
+	InstanceIdStrategyIp          = "ip"
+	InstanceIdStrategyTemplate    = "template"
+	InstanceIdStrategyPod         = "pod"
+)
+
+// InstanceIdGenerator is called once the contributors are applied, so the host, the ip and the port
+// are read from the instance info rather than the properties.
+type InstanceIdGenerator interface {
+	Generate(instanceInfo *InstanceInfo, instanceProperties InstanceProperties) string
+}
+
+type HostAppPortInstanceIdGenerator struct {
+}
+
+func (generator HostAppPortInstanceIdGenerator) Generate(instanceInfo *InstanceInfo, instanceProperties InstanceProperties) string {
+	namePart := instanceProperties.combineParts(instanceInfo.HostName, instanceProperties.ApplicationName, ":")
+	return instanceProperties.combineParts(namePart, instanceInfo.getPort(), ":")
+}
+
+type RandomInstanceIdGenerator struct {
+	random string
+}
+
+func NewRandomInstanceIdGenerator() RandomInstanceIdGenerator {
+	return RandomInstanceIdGenerator{
+		newRandomId(),
+	}
+}
+
+func (generator RandomInstanceIdGenerator) Generate(instanceInfo *InstanceInfo, instanceProperties InstanceProperties) string {
+	namePart := instanceProperties.combineParts(instanceInfo.HostName, instanceProperties.ApplicationName, ":")
+	return instanceProperties.combineParts(namePart, generator.random, ":")
+}
+
+type IpInstanceIdGenerator struct {
+}
+
+func (generator IpInstanceIdGenerator) Generate(instanceInfo *InstanceInfo, instanceProperties InstanceProperties) string {
+	namePart := instanceProperties.combineParts(instanceInfo.IpAddr, instanceProperties.ApplicationName, ":")
+	return instanceProperties.combineParts(namePart, instanceInfo.getPort(), ":")
+}
+
+type TemplateInstanceIdGenerator struct {
+	template string
+	random   string
+}
+
+func NewTemplateInstanceIdGenerator(template string) TemplateInstanceIdGenerator {
+	return TemplateInstanceIdGenerator{
+		template,
+		newRandomId(),
+	}
+}
+
+func (generator TemplateInstanceIdGenerator) Generate(instanceInfo *InstanceInfo, instanceProperties InstanceProperties) string {
+	// the unknown placeholders are kept as they are, so a typo shows up in the instance id
+	return strings.NewReplacer(
+		"${app}", instanceProperties.ApplicationName,
+		"${host}", instanceInfo.HostName,
+		"${ip}", instanceInfo.IpAddr,
+		"${port}", instanceInfo.getPort(),
+		"${pod}", instanceInfo.getPodName(),
+		"${random}", generator.random,
+	).Replace(generator.template)
+}
+
+// PodInstanceIdGenerator keeps the id of an instance stable as long as its pod lives, even if the pod ip changes.
+type PodInstanceIdGenerator struct {
+}
+
+func (generator PodInstanceIdGenerator) Generate(instanceInfo *InstanceInfo, instanceProperties InstanceProperties) string {
+	namePart := instanceProperties.combineParts(instanceInfo.getPodName(), instanceProperties.ApplicationName, ":")
+	return instanceProperties.combineParts(namePart, instanceInfo.getPort(), ":")
+}
+
+func newInstanceIdGenerator(instanceProperties InstanceProperties) InstanceIdGenerator {
+	switch instanceProperties.InstanceIdStrategy {
+	case InstanceIdStrategyRandom:
+		return NewRandomInstanceIdGenerator()
+	case InstanceIdStrategyIp:
+		return IpInstanceIdGenerator{}
+	case InstanceIdStrategyTemplate:
+		return NewTemplateInstanceIdGenerator(instanceProperties.InstanceIdTemplate)
+	case InstanceIdStrategyPod:
+		return PodInstanceIdGenerator{}
+	default:
+		return HostAppPortInstanceIdGenerator{}
+	}
+}
+
+func newRandomId() string {
+	// generated once, the id stays the same as long as the process lives
+	uuid := make([]byte, 16)
+	if _, err := rand.Read(uuid); err != nil {
+		return strconv.FormatInt(time.Now().UnixNano(), 16)
+	}
+	uuid[6] = (uuid[6] & 0x0f) | 0x40
+	uuid[8] = (uuid[8] & 0x3f) | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", uuid[0:4], uuid[4:6], uuid[6:8], uuid[8:10], uuid[10:])
+}
+
+func (instanceInfo *InstanceInfo) getPodName() string {
+	// the kubernetes contributor also reads the pod name from the downward api files
+	if podName := instanceInfo.Metadata[kubernetesMetadataPrefix+"pod-name"]; podName != "" {
+		return podName
+	}
+	if podName := os.Getenv(podNameEnv); podName != "" {
+		return podName
+	}
+	return instanceInfo.HostName
+}
+
+func (instanceInfo *InstanceInfo) getPort() string {
+	// the secure port is the only one advertised if the instance cannot be reached without tls
+	if instanceInfo.SecurePort != nil && instanceInfo.SecurePort.Enabled == "true" &&
+		(instanceInfo.Port == nil || instanceInfo.Port.Enabled != "true") {
+		return strconv.Itoa(instanceInfo.SecurePort.Port)
+	}
+	if instanceInfo.Port == nil {
+		return "0"
+	}
+	return strconv.Itoa(instanceInfo.Port.Port)
+}
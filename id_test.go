@@ -0,0 +1,239 @@
+package eureka
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"testing"
+)
+
+// setTestEnv sets the variable, an empty value unsets it. The returned function restores the previous value.
+func setTestEnv(name, value string) func() {
+	previous, ok := os.LookupEnv(name)
+	if value == "" {
+		_ = os.Unsetenv(name)
+	} else {
+		_ = os.Setenv(name, value)
+	}
+	return func() {
+		if ok {
+			_ = os.Setenv(name, previous)
+		} else {
+			_ = os.Unsetenv(name)
+		}
+	}
+}
+
+func TestDefaultInstanceInfoProvider_InstanceId(t *testing.T) {
+	testCases := []struct {
+		name               string
+		instanceProperties func(instanceProperties *InstanceProperties)
+		expected           string
+		expectedPattern    string
+	}{
+		{
+			name:     "host app port",
+			expected: "host-1:orders:8080",
+		},
+		{
+			name: "host app secure port",
+			instanceProperties: func(instanceProperties *InstanceProperties) {
+				instanceProperties.NonSecurePortEnabled = false
+				instanceProperties.SecurePortEnabled = true
+				instanceProperties.SecurePort = 8443
+			},
+			expected: "host-1:orders:8443",
+		},
+		{
+			name: "ip",
+			instanceProperties: func(instanceProperties *InstanceProperties) {
+				instanceProperties.InstanceIdStrategy = InstanceIdStrategyIp
+			},
+			expected: "10.0.0.1:orders:8080",
+		},
+		{
+			name: "random",
+			instanceProperties: func(instanceProperties *InstanceProperties) {
+				instanceProperties.InstanceIdStrategy = InstanceIdStrategyRandom
+			},
+			expectedPattern: `^host-1:orders:[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`,
+		},
+		{
+			name: "template",
+			instanceProperties: func(instanceProperties *InstanceProperties) {
+				instanceProperties.InstanceIdStrategy = InstanceIdStrategyTemplate
+				instanceProperties.InstanceIdTemplate = "${app}-${ip}-${port}-${host}-${unknown}"
+			},
+			expected: "orders-10.0.0.1-8080-host-1-${unknown}",
+		},
+		{
+			name: "configured instance id",
+			instanceProperties: func(instanceProperties *InstanceProperties) {
+				instanceProperties.InstanceIdStrategy = InstanceIdStrategyIp
+				instanceProperties.InstanceId = "configured"
+			},
+			expected: "configured",
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			instanceProperties := newValidInstanceProperties()
+			instanceProperties.Hostname = "host-1"
+			instanceProperties.IpAddr = "10.0.0.1"
+			if testCase.instanceProperties != nil {
+				testCase.instanceProperties(&instanceProperties)
+			}
+
+			provider := newDefaultInstanceInfoProvider(instanceProperties, *newClientProperties(), nil, nil)
+			first := provider.GetInstanceInfo().InstanceId
+			if testCase.expectedPattern != "" {
+				if !regexp.MustCompile(testCase.expectedPattern).MatchString(first) {
+					t.Errorf("expected an instance id matching %s, got %s", testCase.expectedPattern, first)
+				}
+			} else if first != testCase.expected {
+				t.Errorf("expected instance id %s, got %s", testCase.expected, first)
+			}
+
+			// the id must stay the same when the instance info is built again
+			provider.Refresh(instanceProperties, *newClientProperties())
+			if second := provider.GetInstanceInfo().InstanceId; second != first {
+				t.Errorf("expected the instance id %s to be kept, got %s", first, second)
+			}
+		})
+	}
+}
+
+func TestKubernetesInstanceInfoContributor_KeepsInstanceId(t *testing.T) {
+	defer setTestEnv(podNameEnv, "orders-7d9f")()
+
+	instanceInfo := &InstanceInfo{
+		InstanceId: "host-1:orders:8080",
+		AppName:    "ORDERS",
+	}
+	if err := NewKubernetesInstanceInfoContributor("").Contribute(instanceInfo); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if instanceInfo.InstanceId != "host-1:orders:8080" {
+		t.Errorf("expected the instance id to be kept, got %s", instanceInfo.InstanceId)
+	}
+	if podName := instanceInfo.Metadata[kubernetesMetadataPrefix+"pod-name"]; podName != "orders-7d9f" {
+		t.Errorf("expected the pod name in the metadata, got %q", podName)
+	}
+}
+
+func TestDefaultInstanceInfoProvider_InstanceIdFromContributedInfo(t *testing.T) {
+	defer setTestEnv(podIpEnv, "10.1.2.3")()
+
+	testCases := []struct {
+		name               string
+		instanceIdStrategy string
+		instanceIdTemplate string
+		expected           string
+	}{
+		{
+			name:               "ip",
+			instanceIdStrategy: InstanceIdStrategyIp,
+			expected:           "10.1.2.3:orders:8080",
+		},
+		{
+			name:               "host app port",
+			instanceIdStrategy: InstanceIdStrategyHostAppPort,
+			expected:           "10.1.2.3:orders:8080",
+		},
+		{
+			name:               "template",
+			instanceIdStrategy: InstanceIdStrategyTemplate,
+			instanceIdTemplate: "${ip}/${host}",
+			expected:           "10.1.2.3/10.1.2.3",
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			instanceProperties := newValidInstanceProperties()
+			instanceProperties.Hostname = "host-1"
+			instanceProperties.IpAddr = "10.0.0.1"
+			instanceProperties.InstanceIdStrategy = testCase.instanceIdStrategy
+			instanceProperties.InstanceIdTemplate = testCase.instanceIdTemplate
+
+			provider := newDefaultInstanceInfoProvider(instanceProperties, *newClientProperties(), nil, nil)
+			provider.AddContributor(NewKubernetesInstanceInfoContributor(""))
+
+			if instanceId := provider.GetInstanceInfo().InstanceId; instanceId != testCase.expected {
+				t.Errorf("expected instance id %s, got %s", testCase.expected, instanceId)
+			}
+		})
+	}
+}
+
+func TestDefaultInstanceInfoProvider_PodInstanceId(t *testing.T) {
+	podInfoPath, err := ioutil.TempDir("", "podinfo")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer os.RemoveAll(podInfoPath)
+	if err = ioutil.WriteFile(filepath.Join(podInfoPath, podNameFile), []byte("orders-5c8b\n"), 0644); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	testCases := []struct {
+		name               string
+		podNameEnv         string
+		podInfoPath        string
+		instanceIdStrategy string
+		instanceIdTemplate string
+		expected           string
+	}{
+		{
+			name:               "pod name from the env",
+			podNameEnv:         "orders-7d9f",
+			instanceIdStrategy: InstanceIdStrategyPod,
+			expected:           "orders-7d9f:orders:8080",
+		},
+		{
+			name:               "pod name from the pod info",
+			podInfoPath:        podInfoPath,
+			instanceIdStrategy: InstanceIdStrategyPod,
+			expected:           "orders-5c8b:orders:8080",
+		},
+		{
+			name:               "no pod name",
+			instanceIdStrategy: InstanceIdStrategyPod,
+			expected:           "host-1:orders:8080",
+		},
+		{
+			name:               "pod placeholder",
+			podInfoPath:        podInfoPath,
+			instanceIdStrategy: InstanceIdStrategyTemplate,
+			instanceIdTemplate: "${pod}.${app}",
+			expected:           "orders-5c8b.orders",
+		},
+		{
+			name:               "pod placeholder without pod name",
+			instanceIdStrategy: InstanceIdStrategyTemplate,
+			instanceIdTemplate: "${pod}.${app}",
+			expected:           "host-1.orders",
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			defer setTestEnv(podNameEnv, testCase.podNameEnv)()
+
+			instanceProperties := newValidInstanceProperties()
+			instanceProperties.Hostname = "host-1"
+			instanceProperties.InstanceIdStrategy = testCase.instanceIdStrategy
+			instanceProperties.InstanceIdTemplate = testCase.instanceIdTemplate
+
+			provider := newDefaultInstanceInfoProvider(instanceProperties, *newClientProperties(), nil, nil)
+			provider.AddContributor(NewKubernetesInstanceInfoContributor(testCase.podInfoPath))
+
+			if instanceId := provider.GetInstanceInfo().InstanceId; instanceId != testCase.expected {
+				t.Errorf("expected instance id %s, got %s", testCase.expected, instanceId)
+			}
+		})
+	}
+}
@@ -6,6 +6,24 @@ func init() {
 	// properties
 	core.Register(newClientProperties)
 	core.Register(newInstanceProperties)
-	// instance info provider
+	// instance info
 	core.Register(newDefaultInstanceInfoProvider)
+	core.Register(newInstanceInfoManager)
+	core.Register(newInstanceInfoReplicator)
+	// eureka server
+	core.Register(newHttpClient)
+	core.Register(newRegistryCache)
+	core.Register(newRegistrar)
+	// procyon cloud
+	core.Register(newDiscoveryClient)
+	core.Register(newServiceRegistry)
+	// endpoints and stats
+	core.Register(newWatchHandler)
+	core.Register(newRegistryDebugHandler)
+	core.Register(newClientStatsProvider)
+	core.Register(newMetricsRecorder)
+	core.Register(newContextLogger)
+	// lifecycle
+	core.Register(newPropertiesRefresher)
+	core.Register(newClientLifecycle)
 }
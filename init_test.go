@@ -0,0 +1,152 @@
+package eureka
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestWiredComponents_RecordMetrics(t *testing.T) {
+	server := newFakeEurekaServer(&Applications{
+		Applications: []Application{
+			{
+				Name: "ORDERS",
+				Instances: []InstanceInfo{
+					newTestInstance("ORDERS", "orders-1", InstanceStatusUp, ""),
+				},
+			},
+		},
+	})
+	defer server.Close()
+
+	clientProperties := newClientProperties()
+	clientProperties.ServiceUrl = map[string]string{DefaultZone: server.serviceUrl()}
+	instanceProperties := newValidInstanceProperties()
+
+	// the components are built the way they are registered in init
+	metrics := newMetricsRecorder()
+	httpClient := newHttpClient(*clientProperties, instanceProperties, nil, metrics, nil)
+	provider := newDefaultInstanceInfoProvider(instanceProperties, *clientProperties, nil, nil)
+	manager := newInstanceInfoManager(provider)
+	registryCache := newRegistryCache(httpClient, *clientProperties, nil, metrics)
+	registrar := newRegistrar(httpClient, manager, *clientProperties, nil, metrics)
+	replicator := newInstanceInfoReplicator(httpClient, manager, registrar, *clientProperties, nil, metrics)
+
+	if err := registryCache.Refresh(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	// the first renewal registers the instance, the second one sends a heartbeat
+	for attempt := 0; attempt < 2; attempt++ {
+		if err := registrar.Renew(); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	manager.SetMetadata("zone", "b")
+	if err := replicator.Replicate(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	buffer := &bytes.Buffer{}
+	if _, err := metrics.WriteTo(buffer); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	testCases := []struct {
+		name     string
+		expected string
+	}{
+		{
+			name:     "registry requests",
+			expected: MetricRequests + `{operation="getApplications",status="200"} 1`,
+		},
+		{
+			name:     "register requests",
+			expected: MetricRequests + `{operation="register",status="204"} 2`,
+		},
+		{
+			name:     "registry fetches",
+			expected: MetricRegistryFetches + `{result="success",type="full"} 1`,
+		},
+		{
+			name:     "heartbeats",
+			expected: MetricHeartbeats + `{result="success"} 1`,
+		},
+		{
+			name:     "replications",
+			expected: MetricReplications + `{result="success"} 1`,
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			if !strings.Contains(buffer.String(), testCase.expected) {
+				t.Errorf("expected %s in the metrics:\n%s", testCase.expected, buffer.String())
+			}
+		})
+	}
+}
+
+func TestWiredComponents_Trace(t *testing.T) {
+	server := newFakeEurekaServer(&Applications{})
+	defer server.Close()
+
+	clientProperties := newClientProperties()
+	clientProperties.ServiceUrl = map[string]string{DefaultZone: server.serviceUrl()}
+	instanceProperties := newValidInstanceProperties()
+
+	tracer := NewInMemoryTracer()
+	httpClient := newHttpClient(*clientProperties, instanceProperties, nil, nil, tracer)
+	provider := newDefaultInstanceInfoProvider(instanceProperties, *clientProperties, nil, nil)
+	manager := newInstanceInfoManager(provider)
+	registryCache := newRegistryCache(httpClient, *clientProperties, nil, nil)
+	registrar := newRegistrar(httpClient, manager, *clientProperties, nil, nil)
+	replicator := newInstanceInfoReplicator(httpClient, manager, registrar, *clientProperties, nil, nil)
+
+	if err := registryCache.Refresh(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for attempt := 0; attempt < 2; attempt++ {
+		if err := registrar.Renew(); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	manager.SetMetadata("zone", "b")
+	if err := replicator.Replicate(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	spanCounts := make(map[string]int)
+	for _, span := range tracer.GetSpans() {
+		spanCounts[span.Name]++
+	}
+
+	testCases := []struct {
+		name     string
+		span     string
+		expected int
+	}{
+		{
+			name:     "registry cache",
+			span:     "eureka getApplications",
+			expected: 1,
+		},
+		{
+			name:     "registrar and replicator",
+			span:     "eureka register",
+			expected: 2,
+		},
+		{
+			name:     "heartbeats",
+			span:     "eureka heartbeat",
+			expected: 1,
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			if spanCounts[testCase.span] != testCase.expected {
+				t.Errorf("expected %d %q spans, got %d: %v", testCase.expected, testCase.span, spanCounts[testCase.span], spanCounts)
+			}
+		})
+	}
+}
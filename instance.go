@@ -52,10 +52,9 @@ func (serviceInstance ServiceInstance) GetPort() int {
 }
 
 func (serviceInstance ServiceInstance) IsSecure() bool {
-	if serviceInstance.instanceInfo.SecurePort != nil {
-		return true
-	}
-	return false
+	// https is preferred when the instance accepts both
+	securePort := serviceInstance.instanceInfo.SecurePort
+	return securePort != nil && securePort.Enabled == "true"
 }
 
 func (serviceInstance ServiceInstance) GetMetadata() map[string]string {
@@ -0,0 +1,114 @@
+package eureka
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+const (
+	DefaultPodInfoPath = "/etc/podinfo"
+
+	podNameEnv      = "POD_NAME"
+	podIpEnv        = "POD_IP"
+	podNamespaceEnv = "POD_NAMESPACE"
+	nodeNameEnv     = "NODE_NAME"
+	podLabelsEnv    = "POD_LABELS"
+
+	podNameFile      = "name"
+	podNamespaceFile = "namespace"
+	podLabelsFile    = "labels"
+
+	kubernetesMetadataPrefix = "k8s-"
+	kubernetesLabelPrefix    = kubernetesMetadataPrefix + "label-"
+)
+
+func isKubernetesEnvironment() bool {
+	_, ok := os.LookupEnv("KUBERNETES_SERVICE_HOST")
+	return ok
+}
+
+type KubernetesInstanceInfoContributor struct {
+	podInfoPath string
+}
+
+func NewKubernetesInstanceInfoContributor(podInfoPath string) KubernetesInstanceInfoContributor {
+	return KubernetesInstanceInfoContributor{
+		podInfoPath: podInfoPath,
+	}
+}
+
+func (contributor KubernetesInstanceInfoContributor) Contribute(instanceInfo *InstanceInfo) error {
+	podName := contributor.getValue(podNameEnv, podNameFile)
+	podIp := contributor.getValue(podIpEnv, "")
+	namespace := contributor.getValue(podNamespaceEnv, podNamespaceFile)
+	nodeName := contributor.getValue(nodeNameEnv, "")
+
+	if instanceInfo.Metadata == nil {
+		instanceInfo.Metadata = make(map[string]string)
+	}
+
+	// pod names cannot be resolved by other pods, that's why the ip is used as host name
+	if podIp != "" {
+		instanceInfo.IpAddr = podIp
+		instanceInfo.HostName = podIp
+	}
+
+	// the pod instance id strategy builds the id from the reported pod name
+	if podName != "" {
+		instanceInfo.Metadata[kubernetesMetadataPrefix+"pod-name"] = podName
+	}
+
+	if namespace != "" {
+		instanceInfo.Metadata[kubernetesMetadataPrefix+"namespace"] = namespace
+	}
+
+	if nodeName != "" {
+		instanceInfo.Metadata[kubernetesMetadataPrefix+"node-name"] = nodeName
+	}
+
+	for key, value := range contributor.parseLabels(contributor.getValue(podLabelsEnv, podLabelsFile)) {
+		instanceInfo.Metadata[kubernetesLabelPrefix+key] = value
+	}
+	return nil
+}
+
+func (contributor KubernetesInstanceInfoContributor) getValue(envName string, fileName string) string {
+	if value, ok := os.LookupEnv(envName); ok && value != "" {
+		return value
+	}
+
+	if fileName == "" || contributor.podInfoPath == "" {
+		return ""
+	}
+
+	content, err := ioutil.ReadFile(filepath.Join(contributor.podInfoPath, fileName))
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(content))
+}
+
+func (contributor KubernetesInstanceInfoContributor) parseLabels(content string) map[string]string {
+	labels := make(map[string]string)
+
+	// downward api writes the labels as key="value" lines, the env variable uses commas
+	separators := func(r rune) bool {
+		return r == '\n' || r == ','
+	}
+
+	for _, line := range strings.FieldsFunc(content, separators) {
+		parts := strings.SplitN(strings.TrimSpace(line), "=", 2)
+		if len(parts) != 2 || parts[0] == "" {
+			continue
+		}
+		value := strings.TrimSpace(parts[1])
+		if unquoted, err := strconv.Unquote(value); err == nil {
+			value = unquoted
+		}
+		labels[strings.TrimSpace(parts[0])] = value
+	}
+	return labels
+}
@@ -0,0 +1,124 @@
+package eureka
+
+import (
+	context "github.com/procyon-projects/procyon-context"
+	"sync"
+)
+
+type ClientLifecycle struct {
+	httpClient       DefaultHttpClient
+	clientProperties ClientProperties
+	registryCache    *RegistryCache
+	registrar        *Registrar
+	replicator       *InstanceInfoReplicator
+	logger           Logger
+	lifecycleMu      sync.Mutex
+	started          bool
+}
+
+func newClientLifecycle(httpClient DefaultHttpClient,
+	clientProperties ClientProperties,
+	registryCache *RegistryCache,
+	registrar *Registrar,
+	replicator *InstanceInfoReplicator,
+	logger Logger) *ClientLifecycle {
+	return &ClientLifecycle{
+		httpClient:       httpClient,
+		clientProperties: clientProperties,
+		registryCache:    registryCache,
+		registrar:        registrar,
+		replicator:       replicator,
+		logger:           wrapLogger(logger),
+	}
+}
+
+func (lifecycle *ClientLifecycle) SetLogger(logger Logger) {
+	lifecycle.lifecycleMu.Lock()
+	defer lifecycle.lifecycleMu.Unlock()
+	lifecycle.logger = wrapLogger(logger)
+}
+
+func (lifecycle *ClientLifecycle) GetApplicationListenerName() string {
+	return "github.com.procyon.cloud.eureka.clientLifecycle"
+}
+
+func (lifecycle *ClientLifecycle) SubscribeEvents() []context.ApplicationEventId {
+	return []context.ApplicationEventId{
+		context.ApplicationContextStartedEventId(),
+		context.ApplicationContextStoppedEventId(),
+		context.ApplicationContextClosedEventId(),
+	}
+}
+
+func (lifecycle *ClientLifecycle) OnApplicationEvent(ctx context.Context, event context.ApplicationEvent) {
+	switch event.GetEventId() {
+	case context.ApplicationContextStartedEventId():
+		_ = lifecycle.Start()
+	case context.ApplicationContextStoppedEventId(), context.ApplicationContextClosedEventId():
+		lifecycle.Stop()
+	}
+}
+
+func (lifecycle *ClientLifecycle) Start() error {
+	lifecycle.lifecycleMu.Lock()
+	defer lifecycle.lifecycleMu.Unlock()
+	if lifecycle.started {
+		return nil
+	}
+	lifecycle.started = true
+
+	// the failing parts keep retrying in the background, so the others are started anyway
+	var firstErr error
+	if dnsServiceUrlProvider, ok := lifecycle.httpClient.serviceUrlProvider.(*DnsServiceUrlProvider); ok {
+		firstErr = lifecycle.report("service urls could not be resolved", dnsServiceUrlProvider.Start(), firstErr)
+	}
+
+	if lifecycle.clientProperties.FetchRegistry && lifecycle.registryCache != nil {
+		firstErr = lifecycle.report("registry cache could not be started", lifecycle.registryCache.Start(), firstErr)
+	}
+
+	if lifecycle.clientProperties.RegistryWithEureka && lifecycle.registrar != nil {
+		firstErr = lifecycle.report("registrar could not be started", lifecycle.registrar.Start(), firstErr)
+		if lifecycle.replicator != nil {
+			lifecycle.replicator.Start()
+		}
+	}
+	return firstErr
+}
+
+func (lifecycle *ClientLifecycle) Stop() {
+	lifecycle.lifecycleMu.Lock()
+	defer lifecycle.lifecycleMu.Unlock()
+	if !lifecycle.started {
+		return
+	}
+	lifecycle.started = false
+
+	// the instance is deregistered before anything it depends on goes away
+	if lifecycle.replicator != nil {
+		lifecycle.replicator.Stop()
+	}
+
+	if lifecycle.registrar != nil {
+		_ = lifecycle.registrar.Stop()
+	}
+
+	if lifecycle.registryCache != nil {
+		lifecycle.registryCache.Stop()
+	}
+
+	if dnsServiceUrlProvider, ok := lifecycle.httpClient.serviceUrlProvider.(*DnsServiceUrlProvider); ok {
+		dnsServiceUrlProvider.Stop()
+	}
+}
+
+func (lifecycle *ClientLifecycle) report(message string, err error, firstErr error) error {
+	if err == nil {
+		return firstErr
+	}
+	lifecycle.logger.Error(message, Fields{}.withError(err))
+	if firstErr == nil {
+		return err
+	}
+	return firstErr
+}
@@ -0,0 +1,240 @@
+package eureka
+
+import (
+	"fmt"
+	context "github.com/procyon-projects/procyon-context"
+	"log"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	FieldApp        = "app"
+	FieldInstanceId = "instanceId"
+	FieldServiceUrl = "serviceUrl"
+	FieldStatusCode = "statusCode"
+	FieldAttempt    = "attempt"
+	FieldOperation  = "operation"
+	FieldError      = "error"
+	FieldSuppressed = "suppressed"
+	FieldRegion     = "region"
+	FieldZone       = "zone"
+	FieldInstances  = "instances"
+
+	FieldLocalHashcode  = "localHashcode"
+	FieldRemoteHashcode = "remoteHashcode"
+
+	FieldTask  = "task"
+	FieldDelay = "delay"
+
+	defaultDebugLogInterval = time.Minute
+
+	// the procyon logger writes every entry with the id of a context
+	loggerContextId = context.ContextId("eureka-client")
+)
+
+type Fields map[string]interface{}
+
+type Logger interface {
+	Debug(message string, fields Fields)
+	Info(message string, fields Fields)
+	Warning(message string, fields Fields)
+	Error(message string, fields Fields)
+}
+
+type NoOpLogger struct {
+}
+
+func (logger NoOpLogger) Debug(message string, fields Fields) {
+
+}
+
+func (logger NoOpLogger) Info(message string, fields Fields) {
+
+}
+
+func (logger NoOpLogger) Warning(message string, fields Fields) {
+
+}
+
+func (logger NoOpLogger) Error(message string, fields Fields) {
+
+}
+
+type StandardLogger struct {
+	logger *log.Logger
+}
+
+func NewStandardLogger(logger *log.Logger) StandardLogger {
+	if logger == nil {
+		logger = log.New(os.Stderr, "", log.LstdFlags)
+	}
+	return StandardLogger{
+		logger,
+	}
+}
+
+func (logger StandardLogger) Debug(message string, fields Fields) {
+	logger.print("DEBUG", message, fields)
+}
+
+func (logger StandardLogger) Info(message string, fields Fields) {
+	logger.print("INFO", message, fields)
+}
+
+func (logger StandardLogger) Warning(message string, fields Fields) {
+	logger.print("WARNING", message, fields)
+}
+
+func (logger StandardLogger) Error(message string, fields Fields) {
+	logger.print("ERROR", message, fields)
+}
+
+func (logger StandardLogger) print(level string, message string, fields Fields) {
+	logger.logger.Println(level + " " + fields.format(message))
+}
+
+// ContextLogger writes the logs of the client to the procyon logger of the application.
+type ContextLogger struct {
+	logger context.Logger
+}
+
+func NewContextLogger(logger context.Logger) *ContextLogger {
+	return &ContextLogger{
+		logger,
+	}
+}
+
+// the logger of the application is injected into every component of the client by default
+func newContextLogger(logger context.Logger) *ContextLogger {
+	return NewContextLogger(logger)
+}
+
+func (logger *ContextLogger) Debug(message string, fields Fields) {
+	if logger.logger != nil {
+		logger.logger.Debug(loggerContextId, fields.format(message))
+	}
+}
+
+func (logger *ContextLogger) Info(message string, fields Fields) {
+	if logger.logger != nil {
+		logger.logger.Info(loggerContextId, fields.format(message))
+	}
+}
+
+func (logger *ContextLogger) Warning(message string, fields Fields) {
+	if logger.logger != nil {
+		logger.logger.Warning(loggerContextId, fields.format(message))
+	}
+}
+
+func (logger *ContextLogger) Error(message string, fields Fields) {
+	if logger.logger != nil {
+		logger.logger.Error(loggerContextId, fields.format(message))
+	}
+}
+
+// RateLimitedLogger lets every distinct debug message through at most once per interval,
+// the other levels are never limited.
+type RateLimitedLogger struct {
+	logger      Logger
+	interval    time.Duration
+	lastLogged  map[string]time.Time
+	suppressed  map[string]int
+	debugLogsMu sync.Mutex
+}
+
+func NewRateLimitedLogger(logger Logger, interval time.Duration) *RateLimitedLogger {
+	if logger == nil {
+		logger = NoOpLogger{}
+	}
+	if interval <= 0 {
+		interval = defaultDebugLogInterval
+	}
+	return &RateLimitedLogger{
+		logger:     logger,
+		interval:   interval,
+		lastLogged: make(map[string]time.Time),
+		suppressed: make(map[string]int),
+	}
+}
+
+func (logger *RateLimitedLogger) Debug(message string, fields Fields) {
+	logger.debugLogsMu.Lock()
+	now := time.Now()
+	if lastLogged, ok := logger.lastLogged[message]; ok && now.Sub(lastLogged) < logger.interval {
+		logger.suppressed[message]++
+		logger.debugLogsMu.Unlock()
+		return
+	}
+	suppressed := logger.suppressed[message]
+	logger.lastLogged[message] = now
+	delete(logger.suppressed, message)
+	logger.debugLogsMu.Unlock()
+
+	if suppressed != 0 {
+		fields = fields.with(FieldSuppressed, suppressed)
+	}
+	logger.logger.Debug(message, fields)
+}
+
+func (logger *RateLimitedLogger) Info(message string, fields Fields) {
+	logger.logger.Info(message, fields)
+}
+
+func (logger *RateLimitedLogger) Warning(message string, fields Fields) {
+	logger.logger.Warning(message, fields)
+}
+
+func (logger *RateLimitedLogger) Error(message string, fields Fields) {
+	logger.logger.Error(message, fields)
+}
+
+func wrapLogger(logger Logger) Logger {
+	if logger == nil {
+		return NoOpLogger{}
+	}
+	if _, ok := logger.(*RateLimitedLogger); ok {
+		return logger
+	}
+	return NewRateLimitedLogger(logger, defaultDebugLogInterval)
+}
+
+func (fields Fields) with(key string, value interface{}) Fields {
+	copied := make(Fields, len(fields)+1)
+	for fieldKey, fieldValue := range fields {
+		copied[fieldKey] = fieldValue
+	}
+	copied[key] = value
+	return copied
+}
+
+func (fields Fields) format(message string) string {
+	keys := make([]string, 0, len(fields))
+	for key := range fields {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	builder := strings.Builder{}
+	builder.WriteString(message)
+	for _, key := range keys {
+		builder.WriteString(" " + key + "=")
+		builder.WriteString(fmt.Sprint(fields[key]))
+	}
+	return builder.String()
+}
+
+func (fields Fields) withError(err error) Fields {
+	if err == nil {
+		return fields
+	}
+	copied := fields.with(FieldError, err.Error())
+	if responseError, ok := err.(*ResponseError); ok {
+		copied[FieldStatusCode] = responseError.StatusCode
+	}
+	return copied
+}
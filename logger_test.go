@@ -0,0 +1,129 @@
+package eureka
+
+import (
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+
+	context "github.com/procyon-projects/procyon-context"
+)
+
+type contextLoggerRecorder struct {
+	// the levels the client does not use are left unimplemented
+	context.Logger
+	entries   []string
+	entriesMu sync.Mutex
+}
+
+func (recorder *contextLoggerRecorder) record(ctx interface{}, level string, message interface{}) {
+	recorder.entriesMu.Lock()
+	defer recorder.entriesMu.Unlock()
+	if ctx != loggerContextId {
+		level = "UNKNOWN_CONTEXT " + level
+	}
+	recorder.entries = append(recorder.entries, level+" "+message.(string))
+}
+
+func (recorder *contextLoggerRecorder) Debug(ctx interface{}, message interface{}) {
+	recorder.record(ctx, "DEBUG", message)
+}
+
+func (recorder *contextLoggerRecorder) Info(ctx interface{}, message interface{}) {
+	recorder.record(ctx, "INFO", message)
+}
+
+func (recorder *contextLoggerRecorder) Warning(ctx interface{}, message interface{}) {
+	recorder.record(ctx, "WARNING", message)
+}
+
+func (recorder *contextLoggerRecorder) Error(ctx interface{}, message interface{}) {
+	recorder.record(ctx, "ERROR", message)
+}
+
+func (recorder *contextLoggerRecorder) getEntries() []string {
+	recorder.entriesMu.Lock()
+	defer recorder.entriesMu.Unlock()
+	return append([]string(nil), recorder.entries...)
+}
+
+func TestFields_Format(t *testing.T) {
+	testCases := []struct {
+		name     string
+		fields   Fields
+		expected string
+	}{
+		{
+			name:     "no fields",
+			expected: "instance renewed",
+		},
+		{
+			name:     "sorted fields",
+			fields:   Fields{FieldInstanceId: "orders-1", FieldApp: "ORDERS"},
+			expected: "instance renewed app=ORDERS instanceId=orders-1",
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			if formatted := testCase.fields.format("instance renewed"); formatted != testCase.expected {
+				t.Errorf("expected %q, got %q", testCase.expected, formatted)
+			}
+		})
+	}
+}
+
+func TestContextLogger_LogsFailures(t *testing.T) {
+	server := newFakeEurekaServer(&Applications{})
+	defer server.Close()
+
+	clientProperties := newClientProperties()
+	clientProperties.ServiceUrl = map[string]string{DefaultZone: server.serviceUrl()}
+	instanceProperties := newValidInstanceProperties()
+
+	recorder := &contextLoggerRecorder{}
+	logger := newContextLogger(recorder)
+	httpClient := newHttpClient(*clientProperties, instanceProperties, logger, nil, nil)
+	provider := newDefaultInstanceInfoProvider(instanceProperties, *clientProperties, nil, logger)
+	manager := newInstanceInfoManager(provider)
+	registryCache := newRegistryCache(httpClient, *clientProperties, logger, nil)
+	registrar := newRegistrar(httpClient, manager, *clientProperties, logger, nil)
+
+	// the instance is registered before the server starts failing, so the next renewal sends a heartbeat
+	if err := registrar.Renew(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	server.setStatusCode(http.StatusInternalServerError)
+
+	testCases := []struct {
+		name     string
+		action   func() error
+		expected string
+	}{
+		{
+			name:     "failed renewal",
+			action:   registrar.Renew,
+			expected: "ERROR instance could not be renewed",
+		},
+		{
+			name:     "failed fetch",
+			action:   registryCache.Refresh,
+			expected: "ERROR registry could not be fetched",
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			if err := testCase.action(); err == nil {
+				t.Fatal("expected an error")
+			}
+
+			for _, entry := range recorder.getEntries() {
+				if strings.HasPrefix(entry, testCase.expected) {
+					return
+				}
+			}
+			t.Errorf("expected %q in the log entries: %v", testCase.expected, recorder.getEntries())
+		})
+	}
+}
@@ -0,0 +1,205 @@
+package eureka
+
+import (
+	"reflect"
+	"strconv"
+	"sync"
+	"time"
+)
+
+type InstanceInfoManager struct {
+	instanceInfoProvider InstanceInfoProvider
+	baseInstanceInfo     *InstanceInfo
+	instanceInfo         *InstanceInfo
+	status               InstanceStatus
+	metadata             map[string]string
+	removedMetadata      map[string]bool
+	dirty                bool
+	lastDirtyTimestamp   int64
+	eventListeners       []EventListener
+	instanceInfoMu       sync.Mutex
+}
+
+func newInstanceInfoManager(instanceInfoProvider *DefaultInstanceInfoProvider) *InstanceInfoManager {
+	return &InstanceInfoManager{
+		instanceInfoProvider: instanceInfoProvider,
+		metadata:             make(map[string]string),
+		removedMetadata:      make(map[string]bool),
+		eventListeners:       make([]EventListener, 0),
+	}
+}
+
+func (manager *InstanceInfoManager) GetInstanceInfo() *InstanceInfo {
+	manager.instanceInfoMu.Lock()
+	defer manager.instanceInfoMu.Unlock()
+	manager.sync()
+	// every caller gets its own copy, nobody can change what the others are reading
+	return manager.instanceInfo.copy()
+}
+
+func (manager *InstanceInfoManager) Validate() error {
+	if validatable, ok := manager.instanceInfoProvider.(Validatable); ok {
+		return validatable.Validate()
+	}
+	return nil
+}
+
+func (manager *InstanceInfoManager) PortReady() <-chan struct{} {
+	if portReady, ok := manager.instanceInfoProvider.(interface{ PortReady() <-chan struct{} }); ok {
+		return portReady.PortReady()
+	}
+	return closedChannel
+}
+
+func (manager *InstanceInfoManager) AddEventListener(eventListener EventListener) {
+	manager.instanceInfoMu.Lock()
+	defer manager.instanceInfoMu.Unlock()
+	if eventListener != nil {
+		manager.eventListeners = append(manager.eventListeners, eventListener)
+	}
+}
+
+func (manager *InstanceInfoManager) SetStatus(status InstanceStatus) bool {
+	manager.instanceInfoMu.Lock()
+	manager.sync()
+	previousStatus := manager.instanceInfo.Status
+	if previousStatus == status {
+		manager.instanceInfoMu.Unlock()
+		return false
+	}
+	manager.status = status
+	manager.markDirty()
+	eventListeners := manager.eventListeners
+	manager.instanceInfoMu.Unlock()
+
+	publishEvent(eventListeners, StatusChangedEvent{
+		Timestamp:      time.Now(),
+		PreviousStatus: previousStatus,
+		Status:         status,
+	})
+	return true
+}
+
+func (manager *InstanceInfoManager) SetMetadata(key, value string) bool {
+	manager.instanceInfoMu.Lock()
+	manager.sync()
+	if current, ok := manager.instanceInfo.Metadata[key]; ok && current == value {
+		manager.instanceInfoMu.Unlock()
+		return false
+	}
+	manager.metadata[key] = value
+	delete(manager.removedMetadata, key)
+	manager.markDirty()
+	eventListeners := manager.eventListeners
+	manager.instanceInfoMu.Unlock()
+
+	publishEvent(eventListeners, MetadataChangedEvent{
+		Timestamp: time.Now(),
+		Key:       key,
+		Value:     value,
+	})
+	return true
+}
+
+func (manager *InstanceInfoManager) RemoveMetadata(key string) bool {
+	manager.instanceInfoMu.Lock()
+	manager.sync()
+	if _, ok := manager.instanceInfo.Metadata[key]; !ok {
+		manager.instanceInfoMu.Unlock()
+		return false
+	}
+	delete(manager.metadata, key)
+	manager.removedMetadata[key] = true
+	manager.markDirty()
+	eventListeners := manager.eventListeners
+	manager.instanceInfoMu.Unlock()
+
+	publishEvent(eventListeners, MetadataChangedEvent{
+		Timestamp: time.Now(),
+		Key:       key,
+		Removed:   true,
+	})
+	return true
+}
+
+func (manager *InstanceInfoManager) IsDirty() bool {
+	manager.instanceInfoMu.Lock()
+	defer manager.instanceInfoMu.Unlock()
+	manager.sync()
+	return manager.dirty
+}
+
+func (manager *InstanceInfoManager) GetLastDirtyTimestamp() int64 {
+	manager.instanceInfoMu.Lock()
+	defer manager.instanceInfoMu.Unlock()
+	return manager.lastDirtyTimestamp
+}
+
+func (manager *InstanceInfoManager) getDirtyInstanceInfo() (*InstanceInfo, int64, bool) {
+	manager.instanceInfoMu.Lock()
+	defer manager.instanceInfoMu.Unlock()
+	manager.sync()
+	return manager.instanceInfo.copy(), manager.lastDirtyTimestamp, manager.dirty
+}
+
+func (manager *InstanceInfoManager) unsetDirty(lastDirtyTimestamp int64) {
+	manager.instanceInfoMu.Lock()
+	defer manager.instanceInfoMu.Unlock()
+	// a change made while the previous one was being replicated keeps the info dirty
+	if manager.lastDirtyTimestamp == lastDirtyTimestamp {
+		manager.dirty = false
+	}
+}
+
+func (manager *InstanceInfoManager) sync() {
+	baseInstanceInfo := manager.instanceInfoProvider.GetInstanceInfo()
+	// the provider returns a copy every time, only a different info means a refresh
+	if manager.instanceInfo != nil && reflect.DeepEqual(baseInstanceInfo, manager.baseInstanceInfo) {
+		return
+	}
+
+	// the provider replaced its info, the properties must have been refreshed
+	if manager.baseInstanceInfo != nil {
+		manager.dirty = true
+		if baseTimestamp, err := strconv.ParseInt(baseInstanceInfo.LastDirtyTimestamp, 10, 64); err == nil && baseTimestamp > manager.lastDirtyTimestamp {
+			manager.lastDirtyTimestamp = baseTimestamp
+		}
+	}
+	manager.baseInstanceInfo = baseInstanceInfo
+	manager.rebuild()
+}
+
+func (manager *InstanceInfoManager) markDirty() {
+	now := time.Now().UnixNano() / int64(time.Millisecond)
+	// the server ignores an update which is not newer than the one it already has
+	if now <= manager.lastDirtyTimestamp {
+		now = manager.lastDirtyTimestamp + 1
+	}
+	manager.lastDirtyTimestamp = now
+	manager.dirty = true
+	manager.rebuild()
+}
+
+func (manager *InstanceInfoManager) rebuild() {
+	instanceInfo := manager.baseInstanceInfo.copy()
+	if manager.status != "" {
+		instanceInfo.Status = manager.status
+	}
+
+	if instanceInfo.Metadata == nil {
+		instanceInfo.Metadata = make(MetadataMap)
+	}
+	for key, value := range manager.metadata {
+		instanceInfo.Metadata[key] = value
+	}
+	for key := range manager.removedMetadata {
+		delete(instanceInfo.Metadata, key)
+	}
+
+	if manager.lastDirtyTimestamp != 0 {
+		timestamp := strconv.FormatInt(manager.lastDirtyTimestamp, 10)
+		instanceInfo.LastDirtyTimestamp = timestamp
+		instanceInfo.LastUpdatedTimestamp = timestamp
+	}
+	manager.instanceInfo = instanceInfo
+}
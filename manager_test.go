@@ -0,0 +1,238 @@
+package eureka
+
+import (
+	"strconv"
+	"sync"
+	"testing"
+)
+
+type eventRecorder struct {
+	events   []Event
+	eventsMu sync.Mutex
+}
+
+func (recorder *eventRecorder) OnEvent(event Event) {
+	recorder.eventsMu.Lock()
+	defer recorder.eventsMu.Unlock()
+	recorder.events = append(recorder.events, event)
+}
+
+func (recorder *eventRecorder) getEvents() []Event {
+	recorder.eventsMu.Lock()
+	defer recorder.eventsMu.Unlock()
+	return append([]Event(nil), recorder.events...)
+}
+
+func newTestInstanceInfoManager() *InstanceInfoManager {
+	provider := newDefaultInstanceInfoProvider(newValidInstanceProperties(), *newClientProperties(), nil, nil)
+	return newInstanceInfoManager(provider)
+}
+
+func TestInstanceInfoManager_Changes(t *testing.T) {
+	testCases := []struct {
+		name             string
+		setup            func(manager *InstanceInfoManager)
+		change           func(manager *InstanceInfoManager) bool
+		expectChanged    bool
+		expectedStatus   InstanceStatus
+		expectedMetadata map[string]string
+		expectedEvent    Event
+	}{
+		{
+			name: "status",
+			change: func(manager *InstanceInfoManager) bool {
+				return manager.SetStatus(InstanceStatusDown)
+			},
+			expectChanged:  true,
+			expectedStatus: InstanceStatusDown,
+			expectedEvent: StatusChangedEvent{
+				PreviousStatus: InstanceStatusUp,
+				Status:         InstanceStatusDown,
+			},
+		},
+		{
+			name: "same status",
+			change: func(manager *InstanceInfoManager) bool {
+				return manager.SetStatus(InstanceStatusUp)
+			},
+			expectedStatus: InstanceStatusUp,
+		},
+		{
+			name: "metadata",
+			change: func(manager *InstanceInfoManager) bool {
+				return manager.SetMetadata("lane", "canary")
+			},
+			expectChanged:    true,
+			expectedStatus:   InstanceStatusUp,
+			expectedMetadata: map[string]string{"lane": "canary"},
+			expectedEvent: MetadataChangedEvent{
+				Key:   "lane",
+				Value: "canary",
+			},
+		},
+		{
+			name: "same metadata",
+			setup: func(manager *InstanceInfoManager) {
+				manager.SetMetadata("lane", "canary")
+			},
+			change: func(manager *InstanceInfoManager) bool {
+				return manager.SetMetadata("lane", "canary")
+			},
+			expectedStatus:   InstanceStatusUp,
+			expectedMetadata: map[string]string{"lane": "canary"},
+		},
+		{
+			name: "removed metadata",
+			setup: func(manager *InstanceInfoManager) {
+				manager.SetMetadata("lane", "canary")
+			},
+			change: func(manager *InstanceInfoManager) bool {
+				return manager.RemoveMetadata("lane")
+			},
+			expectChanged:    true,
+			expectedStatus:   InstanceStatusUp,
+			expectedMetadata: map[string]string{"lane": ""},
+			expectedEvent: MetadataChangedEvent{
+				Key:     "lane",
+				Removed: true,
+			},
+		},
+		{
+			name: "missing metadata",
+			change: func(manager *InstanceInfoManager) bool {
+				return manager.RemoveMetadata("lane")
+			},
+			expectedStatus:   InstanceStatusUp,
+			expectedMetadata: map[string]string{"lane": ""},
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			manager := newTestInstanceInfoManager()
+			if testCase.setup != nil {
+				testCase.setup(manager)
+			}
+			recorder := &eventRecorder{}
+			manager.AddEventListener(recorder)
+			previousTimestamp := manager.GetLastDirtyTimestamp()
+
+			if changed := testCase.change(manager); changed != testCase.expectChanged {
+				t.Errorf("expected changed %v, got %v", testCase.expectChanged, changed)
+			}
+
+			instanceInfo := manager.GetInstanceInfo()
+			if instanceInfo.Status != testCase.expectedStatus {
+				t.Errorf("expected status %s, got %s", testCase.expectedStatus, instanceInfo.Status)
+			}
+			for key, value := range testCase.expectedMetadata {
+				if instanceInfo.Metadata[key] != value {
+					t.Errorf("expected the metadata %s=%q, got %q", key, value, instanceInfo.Metadata[key])
+				}
+			}
+
+			// a change is newer than the previous one, so the server does not ignore it
+			timestamp := manager.GetLastDirtyTimestamp()
+			if changed := timestamp > previousTimestamp; changed != testCase.expectChanged {
+				t.Errorf("expected the dirty timestamp to change: %v, %d -> %d", testCase.expectChanged, previousTimestamp, timestamp)
+			}
+			if testCase.expectChanged && instanceInfo.LastDirtyTimestamp != strconv.FormatInt(timestamp, 10) {
+				t.Errorf("expected the last dirty timestamp %d, got %s", timestamp, instanceInfo.LastDirtyTimestamp)
+			}
+			if testCase.expectChanged && !manager.IsDirty() {
+				t.Errorf("expected the info to be dirty")
+			}
+
+			events := recorder.getEvents()
+			if testCase.expectedEvent == nil {
+				if len(events) != 0 {
+					t.Errorf("expected no events, got %v", events)
+				}
+				return
+			}
+			if len(events) != 1 {
+				t.Fatalf("expected a single event, got %v", events)
+			}
+			switch event := events[0].(type) {
+			case StatusChangedEvent:
+				event.Timestamp = testCase.expectedEvent.GetTimestamp()
+				if event != testCase.expectedEvent {
+					t.Errorf("expected the event %+v, got %+v", testCase.expectedEvent, event)
+				}
+			case MetadataChangedEvent:
+				event.Timestamp = testCase.expectedEvent.GetTimestamp()
+				if event != testCase.expectedEvent {
+					t.Errorf("expected the event %+v, got %+v", testCase.expectedEvent, event)
+				}
+			}
+		})
+	}
+}
+
+func TestInstanceInfoManager_UnsetDirty(t *testing.T) {
+	testCases := []struct {
+		name        string
+		changeAfter bool
+		expectDirty bool
+	}{
+		{
+			name: "replicated change",
+		},
+		{
+			name:        "change made while replicating",
+			changeAfter: true,
+			expectDirty: true,
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			manager := newTestInstanceInfoManager()
+			manager.SetStatus(InstanceStatusStarting)
+
+			_, lastDirtyTimestamp, dirty := manager.getDirtyInstanceInfo()
+			if !dirty {
+				t.Fatalf("expected the info to be dirty")
+			}
+			if testCase.changeAfter {
+				manager.SetMetadata("lane", "canary")
+			}
+
+			manager.unsetDirty(lastDirtyTimestamp)
+			if manager.IsDirty() != testCase.expectDirty {
+				t.Errorf("expected the dirty flag %v, got %v", testCase.expectDirty, manager.IsDirty())
+			}
+		})
+	}
+}
+
+func TestInstanceInfoManager_TriggersReplication(t *testing.T) {
+	testCases := []struct {
+		name                       string
+		onDemandUpdateStatusChange bool
+		expectedPending            int
+	}{
+		{
+			name:                       "on demand updates",
+			onDemandUpdateStatusChange: true,
+			expectedPending:            1,
+		},
+		{
+			name: "periodic updates only",
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			clientProperties := newClientProperties()
+			clientProperties.OnDemandUpdateStatusChange = testCase.onDemandUpdateStatusChange
+			manager := newTestInstanceInfoManager()
+			replicator := newInstanceInfoReplicator(nil, manager, nil, *clientProperties, nil, nil)
+
+			manager.SetStatus(InstanceStatusOutOfService)
+			if pending := len(replicator.triggerCh); pending != testCase.expectedPending {
+				t.Errorf("expected %d pending updates, got %d", testCase.expectedPending, pending)
+			}
+		})
+	}
+}
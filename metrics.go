@@ -0,0 +1,59 @@
+package eureka
+
+const (
+	MetricRequests              = "eureka_client_requests_total"
+	MetricRequestDuration       = "eureka_client_request_duration_seconds"
+	MetricHeartbeats            = "eureka_client_heartbeats_total"
+	MetricSecondsSinceRenewal   = "eureka_client_seconds_since_last_successful_renewal"
+	MetricRegistryFetches       = "eureka_client_registry_fetches_total"
+	MetricRegistryFetchDuration = "eureka_client_registry_fetch_duration_seconds"
+	MetricRegistryInstances     = "eureka_client_registry_instances"
+	MetricSelfPreservation      = "eureka_client_self_preservation_active"
+	MetricSelfPreservations     = "eureka_client_self_preservations_total"
+	MetricTaskRuns              = "eureka_client_task_runs_total"
+	MetricTaskDelay             = "eureka_client_task_delay_seconds"
+	MetricReplications          = "eureka_client_instance_info_replications_total"
+
+	LabelOperation = "operation"
+	LabelStatus    = "status"
+	LabelResult    = "result"
+	LabelFetchType = "type"
+	LabelApp       = "app"
+	LabelTask      = "task"
+
+	ResultSuccess     = "success"
+	ResultFailure     = "failure"
+	ResultRateLimited = "rate_limited"
+	ResultTimeout     = "timeout"
+	ResultRejected    = "rejected"
+
+	FetchTypeFull  = "full"
+	FetchTypeDelta = "delta"
+)
+
+type MetricsRecorder interface {
+	IncrementCounter(name string, labels map[string]string)
+	ObserveHistogram(name string, value float64, labels map[string]string)
+	SetGauge(name string, value float64, labels map[string]string)
+}
+
+// GaugeFuncRecorder is implemented by the recorders which read a gauge when
+// they are scraped instead of keeping the last value set.
+type GaugeFuncRecorder interface {
+	SetGaugeFunc(name string, gaugeFunc func() float64, labels map[string]string)
+}
+
+type NoOpMetricsRecorder struct {
+}
+
+func (recorder NoOpMetricsRecorder) IncrementCounter(name string, labels map[string]string) {
+
+}
+
+func (recorder NoOpMetricsRecorder) ObserveHistogram(name string, value float64, labels map[string]string) {
+
+}
+
+func (recorder NoOpMetricsRecorder) SetGauge(name string, value float64, labels map[string]string) {
+
+}
@@ -0,0 +1,107 @@
+package eureka
+
+import "time"
+
+func (cache *RegistryCache) preserveInstances(previous *Applications, fetched *Applications) *Applications {
+	thresholdPercent := cache.clientProperties.SelfPreservationThresholdPercent
+	if previous == nil || thresholdPercent <= 0 {
+		return fetched
+	}
+
+	now := time.Now()
+	gracePeriod := time.Duration(cache.clientProperties.SelfPreservationGracePeriodSeconds) * time.Second
+	applications := fetched.copy()
+
+	for _, previousApplication := range previous.Applications {
+		fetchedApplication := fetched.GetApplication(previousApplication.Name)
+
+		removed := 0
+		for _, instance := range previousApplication.Instances {
+			if fetchedApplication == nil || fetchedApplication.indexOf(instance.InstanceId) == -1 {
+				removed++
+			}
+		}
+
+		heldUntil, held := cache.heldApps[previousApplication.Name]
+		if removed*100 <= thresholdPercent*len(previousApplication.Instances) {
+			if held {
+				cache.releaseApp(previousApplication.Name, false)
+			}
+			continue
+		}
+
+		if !held {
+			heldUntil = now.Add(gracePeriod)
+			cache.heldApps[previousApplication.Name] = heldUntil
+			cache.holdApp(previousApplication, fetchedApplication, heldUntil)
+		} else if !now.Before(heldUntil) {
+			cache.releaseApp(previousApplication.Name, true)
+			continue
+		}
+
+		// the previous instances are kept along with the ones which appeared in the meantime
+		application := applications.GetApplication(previousApplication.Name)
+		if application == nil {
+			applications.Applications = append(applications.Applications, Application{Name: previousApplication.Name})
+			application = &applications.Applications[len(applications.Applications)-1]
+		}
+		for _, instance := range previousApplication.Instances {
+			if application.indexOf(instance.InstanceId) == -1 {
+				application.Instances = append(application.Instances, instance)
+			}
+		}
+	}
+
+	// an app missing from the previous view has nothing left to hold
+	for appName := range cache.heldApps {
+		if previous.GetApplication(appName) == nil {
+			cache.releaseApp(appName, false)
+		}
+	}
+
+	applications.AppsHashcode = applications.ComputeHashcode()
+	return applications
+}
+
+func (cache *RegistryCache) holdApp(previousApplication Application, fetchedApplication *Application, heldUntil time.Time) {
+	fetchedInstances := 0
+	if fetchedApplication != nil {
+		fetchedInstances = len(fetchedApplication.Instances)
+	}
+
+	cache.logger.Warning("most of the instances disappeared, the previous ones are kept", Fields{
+		FieldApp:       previousApplication.Name,
+		FieldInstances: fetchedInstances,
+	})
+
+	labels := map[string]string{
+		LabelApp: previousApplication.Name,
+	}
+	cache.metrics.IncrementCounter(MetricSelfPreservations, labels)
+	cache.metrics.SetGauge(MetricSelfPreservation, 1, labels)
+
+	cache.publishEvent(SelfPreservationStartedEvent{
+		Timestamp:         time.Now(),
+		App:               previousApplication.Name,
+		PreviousInstances: len(previousApplication.Instances),
+		FetchedInstances:  fetchedInstances,
+		HeldUntil:         heldUntil,
+	})
+}
+
+func (cache *RegistryCache) releaseApp(appName string, expired bool) {
+	delete(cache.heldApps, appName)
+
+	cache.logger.Info("the instances are not held anymore", Fields{
+		FieldApp: appName,
+	})
+	cache.metrics.SetGauge(MetricSelfPreservation, 0, map[string]string{
+		LabelApp: appName,
+	})
+
+	cache.publishEvent(SelfPreservationEndedEvent{
+		Timestamp: time.Now(),
+		App:       appName,
+		Expired:   expired,
+	})
+}
@@ -0,0 +1,114 @@
+package eureka
+
+import (
+	"strconv"
+	"testing"
+)
+
+func newPreservationTestApplications(count int) *Applications {
+	application := Application{Name: "ORDERS"}
+	for index := 0; index < count; index++ {
+		application.Instances = append(application.Instances, newTestInstance("ORDERS", "orders-"+strconv.Itoa(index), InstanceStatusUp, ""))
+	}
+	return &Applications{Applications: []Application{application}}
+}
+
+func TestRegistryCache_PreserveInstances(t *testing.T) {
+	type step struct {
+		fetchedInstances  int
+		expectedInstances int
+		expectedEvent     Event
+	}
+
+	testCases := []struct {
+		name               string
+		gracePeriodSeconds int
+		steps              []step
+	}{
+		{
+			name:               "drop below the threshold keeps the previous registry",
+			gracePeriodSeconds: 300,
+			steps: []step{
+				{fetchedInstances: 4, expectedInstances: 4},
+				{fetchedInstances: 1, expectedInstances: 4, expectedEvent: SelfPreservationStartedEvent{}},
+				{fetchedInstances: 1, expectedInstances: 4},
+			},
+		},
+		{
+			name:               "recovery releases the registry",
+			gracePeriodSeconds: 300,
+			steps: []step{
+				{fetchedInstances: 4, expectedInstances: 4},
+				{fetchedInstances: 1, expectedInstances: 4, expectedEvent: SelfPreservationStartedEvent{}},
+				{fetchedInstances: 3, expectedInstances: 3, expectedEvent: SelfPreservationEndedEvent{}},
+			},
+		},
+		{
+			name: "expired grace period releases the registry",
+			steps: []step{
+				{fetchedInstances: 4, expectedInstances: 4},
+				{fetchedInstances: 1, expectedInstances: 4, expectedEvent: SelfPreservationStartedEvent{}},
+				{fetchedInstances: 1, expectedInstances: 1, expectedEvent: SelfPreservationEndedEvent{Expired: true}},
+			},
+		},
+		{
+			name:               "drop within the threshold",
+			gracePeriodSeconds: 300,
+			steps: []step{
+				{fetchedInstances: 4, expectedInstances: 4},
+				{fetchedInstances: 2, expectedInstances: 2},
+			},
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			server := newFakeEurekaServer(&Applications{})
+			defer server.Close()
+
+			clientProperties := newClientProperties()
+			clientProperties.ServiceUrl = map[string]string{DefaultZone: server.serviceUrl()}
+			clientProperties.DisableDelta = true
+			clientProperties.SelfPreservationThresholdPercent = 50
+			clientProperties.SelfPreservationGracePeriodSeconds = testCase.gracePeriodSeconds
+
+			registryCache := newRegistryCache(NewDefaultHttpClient(server.serviceUrl()), *clientProperties, nil, nil)
+			recorder := &eventRecorder{}
+			registryCache.AddEventListener(recorder)
+
+			for index, step := range testCase.steps {
+				server.setApplications(newPreservationTestApplications(step.fetchedInstances))
+				previousEvents := len(recorder.getEvents())
+				if err := registryCache.Refresh(); err != nil {
+					t.Fatalf("step %d: unexpected error: %v", index, err)
+				}
+
+				if count := registryCache.GetApplications().GetInstancesCount(); count != step.expectedInstances {
+					t.Errorf("step %d: expected %d instances, got %d", index, step.expectedInstances, count)
+				}
+
+				var event Event
+				for _, recorded := range recorder.getEvents()[previousEvents:] {
+					switch recorded.(type) {
+					case SelfPreservationStartedEvent, SelfPreservationEndedEvent:
+						event = recorded
+					}
+				}
+				switch expected := step.expectedEvent.(type) {
+				case nil:
+					if event != nil {
+						t.Errorf("step %d: expected no preservation event, got %+v", index, event)
+					}
+				case SelfPreservationStartedEvent:
+					if started, ok := event.(SelfPreservationStartedEvent); !ok || started.App != "ORDERS" {
+						t.Errorf("step %d: expected the preservation to start, got %+v", index, event)
+					}
+				case SelfPreservationEndedEvent:
+					if ended, ok := event.(SelfPreservationEndedEvent); !ok || ended.Expired != expected.Expired {
+						t.Errorf("step %d: expected the preservation to end with expired %v, got %+v", index, expected.Expired, event)
+					}
+				}
+			}
+		})
+	}
+}
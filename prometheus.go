@@ -0,0 +1,250 @@
+package eureka
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+var DefaultHistogramBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+type prometheusHistogram struct {
+	bucketCounts []uint64
+	sum          float64
+	count        uint64
+}
+
+type PrometheusMetricsRecorder struct {
+	buckets    []float64
+	counters   map[string]map[string]float64
+	gauges     map[string]map[string]float64
+	gaugeFuncs map[string]map[string]func() float64
+	histograms map[string]map[string]*prometheusHistogram
+	metricsMu  sync.Mutex
+}
+
+func NewPrometheusMetricsRecorder(buckets ...float64) *PrometheusMetricsRecorder {
+	if len(buckets) == 0 {
+		buckets = DefaultHistogramBuckets
+	}
+	sortedBuckets := append([]float64(nil), buckets...)
+	sort.Float64s(sortedBuckets)
+
+	return &PrometheusMetricsRecorder{
+		buckets:    sortedBuckets,
+		counters:   make(map[string]map[string]float64),
+		gauges:     make(map[string]map[string]float64),
+		gaugeFuncs: make(map[string]map[string]func() float64),
+		histograms: make(map[string]map[string]*prometheusHistogram),
+	}
+}
+
+// the components of the client share it, so all of their metrics are served by one handler
+func newMetricsRecorder() *PrometheusMetricsRecorder {
+	return NewPrometheusMetricsRecorder()
+}
+
+func (recorder *PrometheusMetricsRecorder) IncrementCounter(name string, labels map[string]string) {
+	recorder.metricsMu.Lock()
+	defer recorder.metricsMu.Unlock()
+	if _, ok := recorder.counters[name]; !ok {
+		recorder.counters[name] = make(map[string]float64)
+	}
+	recorder.counters[name][recorder.formatLabels(labels)]++
+}
+
+func (recorder *PrometheusMetricsRecorder) ObserveHistogram(name string, value float64, labels map[string]string) {
+	recorder.metricsMu.Lock()
+	defer recorder.metricsMu.Unlock()
+	if _, ok := recorder.histograms[name]; !ok {
+		recorder.histograms[name] = make(map[string]*prometheusHistogram)
+	}
+
+	formattedLabels := recorder.formatLabels(labels)
+	histogram, ok := recorder.histograms[name][formattedLabels]
+	if !ok {
+		histogram = &prometheusHistogram{
+			bucketCounts: make([]uint64, len(recorder.buckets)),
+		}
+		recorder.histograms[name][formattedLabels] = histogram
+	}
+
+	for index, bucket := range recorder.buckets {
+		if value <= bucket {
+			histogram.bucketCounts[index]++
+		}
+	}
+	histogram.sum += value
+	histogram.count++
+}
+
+func (recorder *PrometheusMetricsRecorder) SetGauge(name string, value float64, labels map[string]string) {
+	recorder.metricsMu.Lock()
+	defer recorder.metricsMu.Unlock()
+	if _, ok := recorder.gauges[name]; !ok {
+		recorder.gauges[name] = make(map[string]float64)
+	}
+	recorder.gauges[name][recorder.formatLabels(labels)] = value
+}
+
+func (recorder *PrometheusMetricsRecorder) SetGaugeFunc(name string, gaugeFunc func() float64, labels map[string]string) {
+	recorder.metricsMu.Lock()
+	defer recorder.metricsMu.Unlock()
+	if _, ok := recorder.gaugeFuncs[name]; !ok {
+		recorder.gaugeFuncs[name] = make(map[string]func() float64)
+	}
+	recorder.gaugeFuncs[name][recorder.formatLabels(labels)] = gaugeFunc
+}
+
+func (recorder *PrometheusMetricsRecorder) WriteTo(writer io.Writer) (int64, error) {
+	// the functions are called outside the lock, they might take the locks of the components they read
+	gaugeFuncValues := recorder.readGaugeFuncs()
+
+	recorder.metricsMu.Lock()
+	buffer := &bytes.Buffer{}
+
+	for _, name := range recorder.sortedNames(recorder.counters) {
+		recorder.writeSamples(buffer, name, "counter", recorder.counters[name])
+	}
+
+	gauges := make(map[string]map[string]float64, len(recorder.gauges)+len(gaugeFuncValues))
+	for _, source := range []map[string]map[string]float64{recorder.gauges, gaugeFuncValues} {
+		for name, samples := range source {
+			if _, ok := gauges[name]; !ok {
+				gauges[name] = make(map[string]float64, len(samples))
+			}
+			for labels, value := range samples {
+				gauges[name][labels] = value
+			}
+		}
+	}
+
+	for _, name := range recorder.sortedNames(gauges) {
+		recorder.writeSamples(buffer, name, "gauge", gauges[name])
+	}
+
+	histogramNames := make([]string, 0, len(recorder.histograms))
+	for name := range recorder.histograms {
+		histogramNames = append(histogramNames, name)
+	}
+	sort.Strings(histogramNames)
+
+	for _, name := range histogramNames {
+		buffer.WriteString("# TYPE " + name + " histogram\n")
+		histograms := recorder.histograms[name]
+
+		labelSets := make([]string, 0, len(histograms))
+		for labels := range histograms {
+			labelSets = append(labelSets, labels)
+		}
+		sort.Strings(labelSets)
+
+		for _, labels := range labelSets {
+			histogram := histograms[labels]
+			for index, bucket := range recorder.buckets {
+				buffer.WriteString(name + "_bucket" + recorder.appendLabel(labels, "le", recorder.formatValue(bucket)) +
+					" " + strconv.FormatUint(histogram.bucketCounts[index], 10) + "\n")
+			}
+			buffer.WriteString(name + "_bucket" + recorder.appendLabel(labels, "le", "+Inf") +
+				" " + strconv.FormatUint(histogram.count, 10) + "\n")
+			buffer.WriteString(name + "_sum" + recorder.wrapLabels(labels) + " " + recorder.formatValue(histogram.sum) + "\n")
+			buffer.WriteString(name + "_count" + recorder.wrapLabels(labels) + " " + strconv.FormatUint(histogram.count, 10) + "\n")
+		}
+	}
+	recorder.metricsMu.Unlock()
+
+	return buffer.WriteTo(writer)
+}
+
+func (recorder *PrometheusMetricsRecorder) ServeHTTP(writer http.ResponseWriter, request *http.Request) {
+	writer.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	writer.WriteHeader(http.StatusOK)
+	_, _ = recorder.WriteTo(writer)
+}
+
+func (recorder *PrometheusMetricsRecorder) readGaugeFuncs() map[string]map[string]float64 {
+	recorder.metricsMu.Lock()
+	gaugeFuncs := make(map[string]map[string]func() float64, len(recorder.gaugeFuncs))
+	for name, funcs := range recorder.gaugeFuncs {
+		gaugeFuncs[name] = make(map[string]func() float64, len(funcs))
+		for labels, gaugeFunc := range funcs {
+			gaugeFuncs[name][labels] = gaugeFunc
+		}
+	}
+	recorder.metricsMu.Unlock()
+
+	values := make(map[string]map[string]float64, len(gaugeFuncs))
+	for name, funcs := range gaugeFuncs {
+		values[name] = make(map[string]float64, len(funcs))
+		for labels, gaugeFunc := range funcs {
+			values[name][labels] = gaugeFunc()
+		}
+	}
+	return values
+}
+
+func (recorder *PrometheusMetricsRecorder) writeSamples(buffer *bytes.Buffer, name string, metricType string, samples map[string]float64) {
+	buffer.WriteString("# TYPE " + name + " " + metricType + "\n")
+
+	labelSets := make([]string, 0, len(samples))
+	for labels := range samples {
+		labelSets = append(labelSets, labels)
+	}
+	sort.Strings(labelSets)
+
+	for _, labels := range labelSets {
+		buffer.WriteString(name + recorder.wrapLabels(labels) + " " + recorder.formatValue(samples[labels]) + "\n")
+	}
+}
+
+func (recorder *PrometheusMetricsRecorder) sortedNames(metrics map[string]map[string]float64) []string {
+	names := make([]string, 0, len(metrics))
+	for name := range metrics {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func (recorder *PrometheusMetricsRecorder) formatLabels(labels map[string]string) string {
+	keys := make([]string, 0, len(labels))
+	for key := range labels {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	pairs := make([]string, 0, len(keys))
+	for _, key := range keys {
+		pairs = append(pairs, key+"=\""+recorder.escapeLabelValue(labels[key])+"\"")
+	}
+	return strings.Join(pairs, ",")
+}
+
+func (recorder *PrometheusMetricsRecorder) escapeLabelValue(value string) string {
+	value = strings.Replace(value, "\\", "\\\\", -1)
+	value = strings.Replace(value, "\"", "\\\"", -1)
+	return strings.Replace(value, "\n", "\\n", -1)
+}
+
+func (recorder *PrometheusMetricsRecorder) wrapLabels(labels string) string {
+	if labels == "" {
+		return ""
+	}
+	return "{" + labels + "}"
+}
+
+func (recorder *PrometheusMetricsRecorder) appendLabel(labels string, key string, value string) string {
+	label := key + "=\"" + value + "\""
+	if labels == "" {
+		return "{" + label + "}"
+	}
+	return "{" + labels + "," + label + "}"
+}
+
+func (recorder *PrometheusMetricsRecorder) formatValue(value float64) string {
+	return strconv.FormatFloat(value, 'g', -1, 64)
+}
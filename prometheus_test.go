@@ -0,0 +1,91 @@
+package eureka
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestPrometheusMetricsRecorder_WriteTo(t *testing.T) {
+	testCases := []struct {
+		name     string
+		record   func(recorder *PrometheusMetricsRecorder)
+		expected []string
+	}{
+		{
+			name: "counter with labels",
+			record: func(recorder *PrometheusMetricsRecorder) {
+				recorder.IncrementCounter(MetricHeartbeats, map[string]string{LabelResult: ResultSuccess})
+				recorder.IncrementCounter(MetricHeartbeats, map[string]string{LabelResult: ResultSuccess})
+			},
+			expected: []string{
+				"# TYPE " + MetricHeartbeats + " counter",
+				MetricHeartbeats + `{result="success"} 2`,
+			},
+		},
+		{
+			name: "gauge function read at every scrape",
+			record: func(recorder *PrometheusMetricsRecorder) {
+				value := 0.0
+				recorder.SetGaugeFunc("test_gauge", func() float64 {
+					value++
+					return value
+				}, nil)
+				_, _ = recorder.WriteTo(&bytes.Buffer{})
+			},
+			expected: []string{
+				"# TYPE test_gauge gauge",
+				"test_gauge 2",
+			},
+		},
+		{
+			name: "gauge function replaces the set value",
+			record: func(recorder *PrometheusMetricsRecorder) {
+				recorder.SetGauge("test_gauge", 1, map[string]string{"key": "value"})
+				recorder.SetGaugeFunc("test_gauge", func() float64 {
+					return 3
+				}, map[string]string{"key": "value"})
+			},
+			expected: []string{
+				`test_gauge{key="value"} 3`,
+			},
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			recorder := NewPrometheusMetricsRecorder()
+			testCase.record(recorder)
+
+			buffer := &bytes.Buffer{}
+			if _, err := recorder.WriteTo(buffer); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			for _, line := range testCase.expected {
+				if !strings.Contains(buffer.String(), line+"\n") {
+					t.Errorf("expected %q in\n%s", line, buffer.String())
+				}
+			}
+		})
+	}
+}
+
+func TestRegistrar_SecondsSinceRenewal(t *testing.T) {
+	recorder := NewPrometheusMetricsRecorder()
+	registrar := &Registrar{}
+	registrar.SetMetricsRecorder(recorder)
+
+	buffer := &bytes.Buffer{}
+	_, _ = recorder.WriteTo(buffer)
+	if !strings.Contains(buffer.String(), MetricSecondsSinceRenewal+" NaN\n") {
+		t.Errorf("expected NaN before the first renewal, got\n%s", buffer.String())
+	}
+
+	// no heartbeat is sent after this one, the gauge still has to grow
+	registrar.lastSuccessfulHeartbeat = time.Now().Add(-time.Minute)
+	values := recorder.readGaugeFuncs()[MetricSecondsSinceRenewal]
+	if seconds := values[""]; seconds < 60 {
+		t.Errorf("expected at least 60 seconds, got %v", seconds)
+	}
+}
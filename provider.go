@@ -1,93 +1,356 @@
 package eureka
 
 import (
+	"errors"
 	core "github.com/procyon-projects/procyon-core"
 	"net/url"
 	"os"
+	"reflect"
 	"strconv"
 	"strings"
 	"sync"
+	"time"
 )
 
+const defaultContributionRetryInterval = 30 * time.Second
+
 type InstanceInfoProvider interface {
 	GetInstanceInfo() *InstanceInfo
 }
 
 type DefaultInstanceInfoProvider struct {
 	instanceProperties InstanceProperties
+	clientProperties   ClientProperties
 	instanceInfo       *InstanceInfo
 	instanceInfoMu     sync.RWMutex
+	buildMu            sync.Mutex
 	environment        core.Environment
+	contributors       []InstanceInfoContributor
+	idGenerator        InstanceIdGenerator
+	customIdGenerator  bool
+	listenerPort       int
+	secureListenerPort int
+	// a failed contributor leaves a partial info behind, it is built again after the retry interval
+	contributionFailedAt      time.Time
+	contributionRetryInterval time.Duration
+	portReadyCh               chan struct{}
+	portReadyOnce             sync.Once
+	logger                    Logger
 }
 
-func newDefaultInstanceInfoProvider(instanceProperties InstanceProperties, environment core.Environment) *DefaultInstanceInfoProvider {
-	return &DefaultInstanceInfoProvider{
-		instanceProperties: instanceProperties,
-		environment:        environment,
+func newDefaultInstanceInfoProvider(instanceProperties InstanceProperties, clientProperties ClientProperties, environment core.Environment, logger Logger) *DefaultInstanceInfoProvider {
+	provider := &DefaultInstanceInfoProvider{
+		instanceProperties:        instanceProperties,
+		clientProperties:          clientProperties,
+		environment:               environment,
+		contributors:              make([]InstanceInfoContributor, 0),
+		idGenerator:               newInstanceIdGenerator(instanceProperties),
+		portReadyCh:               make(chan struct{}),
+		logger:                    wrapLogger(logger),
+		contributionRetryInterval: defaultContributionRetryInterval,
+	}
+	provider.notifyPortReady()
+
+	if instanceProperties.DataCenterInfo.Name == DataCenterAmazon {
+		provider.AddContributor(newAmazonInstanceInfoContributor(instanceProperties))
 	}
+
+	if isKubernetesEnvironment() {
+		provider.AddContributor(NewKubernetesInstanceInfoContributor(DefaultPodInfoPath))
+	}
+	return provider
 }
 
-func (provider *DefaultInstanceInfoProvider) GetInstanceInfo() *InstanceInfo {
+func (provider *DefaultInstanceInfoProvider) SetLogger(logger Logger) {
 	provider.instanceInfoMu.Lock()
 	defer provider.instanceInfoMu.Unlock()
-	if provider.instanceInfo != nil {
-		return provider.instanceInfo
+	provider.logger = wrapLogger(logger)
+}
+
+func (provider *DefaultInstanceInfoProvider) AddContributor(contributor InstanceInfoContributor) {
+	provider.instanceInfoMu.Lock()
+	defer provider.instanceInfoMu.Unlock()
+	if contributor != nil {
+		provider.contributors = append(provider.contributors, contributor)
+	}
+}
+
+func (provider *DefaultInstanceInfoProvider) SetInstanceIdGenerator(idGenerator InstanceIdGenerator) {
+	provider.instanceInfoMu.Lock()
+	defer provider.instanceInfoMu.Unlock()
+	if idGenerator == nil {
+		provider.idGenerator = newInstanceIdGenerator(provider.instanceProperties)
+		provider.customIdGenerator = false
+		return
+	}
+	provider.idGenerator = idGenerator
+	provider.customIdGenerator = true
+}
+
+func (provider *DefaultInstanceInfoProvider) Validate() error {
+	provider.instanceInfoMu.RLock()
+	defer provider.instanceInfoMu.RUnlock()
+	return validateProperties(provider.clientProperties, provider.instanceProperties)
+}
+
+// InitializePea is called once the properties are bound, the application does
+// not start with invalid properties.
+func (provider *DefaultInstanceInfoProvider) InitializePea() error {
+	return provider.Validate()
+}
+
+func (provider *DefaultInstanceInfoProvider) GetInstanceInfo() *InstanceInfo {
+	// the stored info is shared, the callers get their own copy to change
+	if instanceInfo := provider.getCurrentInstanceInfo(); instanceInfo != nil && !provider.isContributionRetryDue() {
+		return instanceInfo.copy()
 	}
 
-	port := provider.environment.GetProperty("server.port", "8080").(string)
-	hostName, err := os.Hostname()
-	if err != nil {
-		panic(err)
+	provider.buildMu.Lock()
+	defer provider.buildMu.Unlock()
+	// another caller might have built it while this one was waiting
+	if instanceInfo := provider.getCurrentInstanceInfo(); instanceInfo != nil {
+		if provider.isContributionRetryDue() {
+			provider.rebuild()
+		}
+		return provider.getCurrentInstanceInfo().copy()
 	}
 
+	instanceInfo, contributed := provider.buildInstanceInfo()
+	provider.instanceInfoMu.Lock()
+	provider.instanceInfo = instanceInfo
+	provider.setContributed(contributed)
+	provider.instanceInfoMu.Unlock()
+	return instanceInfo.copy()
+}
+
+func (provider *DefaultInstanceInfoProvider) isContributionRetryDue() bool {
+	provider.instanceInfoMu.RLock()
+	defer provider.instanceInfoMu.RUnlock()
+	return !provider.contributionFailedAt.IsZero() && time.Since(provider.contributionFailedAt) >= provider.contributionRetryInterval
+}
+
+// setContributed is called with the instance info lock held.
+func (provider *DefaultInstanceInfoProvider) setContributed(contributed bool) {
+	if contributed {
+		provider.contributionFailedAt = time.Time{}
+	} else {
+		provider.contributionFailedAt = time.Now()
+	}
+}
+
+func (provider *DefaultInstanceInfoProvider) getCurrentInstanceInfo() *InstanceInfo {
+	provider.instanceInfoMu.RLock()
+	defer provider.instanceInfoMu.RUnlock()
+	return provider.instanceInfo
+}
+
+// SetPort reports the port a listener is bound to, secure tells whether it is
+// the https listener. It replaces the port 0 of the enabled port it belongs to.
+func (provider *DefaultInstanceInfoProvider) SetPort(port int, secure bool) error {
+	if port <= 0 || port > maxPort {
+		return errors.New("eureka: listener port is out of range: " + strconv.Itoa(port))
+	}
+
+	provider.buildMu.Lock()
+	defer provider.buildMu.Unlock()
+
+	provider.instanceInfoMu.Lock()
+	if secure {
+		provider.secureListenerPort = port
+	} else {
+		provider.listenerPort = port
+	}
+	provider.instanceInfoMu.Unlock()
+
+	provider.rebuild()
+	provider.notifyPortReady()
+	return nil
+}
+
+func (provider *DefaultInstanceInfoProvider) PortReady() <-chan struct{} {
+	return provider.portReadyCh
+}
+
+func (provider *DefaultInstanceInfoProvider) notifyPortReady() {
+	provider.instanceInfoMu.RLock()
+	portKnown := provider.isPortKnown()
+	provider.instanceInfoMu.RUnlock()
+
+	if portKnown {
+		provider.portReadyOnce.Do(func() {
+			close(provider.portReadyCh)
+		})
+	}
+}
+
+func (provider *DefaultInstanceInfoProvider) Refresh(instanceProperties InstanceProperties, clientProperties ClientProperties) bool {
+	provider.buildMu.Lock()
+	defer provider.buildMu.Unlock()
+
+	provider.instanceInfoMu.Lock()
+	if !provider.customIdGenerator && (instanceProperties.InstanceIdStrategy != provider.instanceProperties.InstanceIdStrategy ||
+		instanceProperties.InstanceIdTemplate != provider.instanceProperties.InstanceIdTemplate) {
+		provider.idGenerator = newInstanceIdGenerator(instanceProperties)
+	}
+	provider.instanceProperties = instanceProperties
+	provider.clientProperties = clientProperties
+	provider.instanceInfoMu.Unlock()
+
+	changed := provider.rebuild()
+	provider.notifyPortReady()
+	return changed
+}
+
+func (provider *DefaultInstanceInfoProvider) rebuild() bool {
+	current := provider.getCurrentInstanceInfo()
+	if current == nil {
+		return false
+	}
+
+	instanceInfo, contributed := provider.buildInstanceInfo()
+	instanceInfo.Status = current.Status
+	instanceInfo.OverriddenStatus = current.OverriddenStatus
+	instanceInfo.LastDirtyTimestamp = current.LastDirtyTimestamp
+	if reflect.DeepEqual(instanceInfo, current) {
+		provider.instanceInfoMu.Lock()
+		provider.setContributed(contributed)
+		provider.instanceInfoMu.Unlock()
+		return false
+	}
+
+	// the info is replaced instead of being modified, the heartbeats might be reading the current one
+	instanceInfo.LastDirtyTimestamp = strconv.FormatInt(time.Now().UnixNano()/int64(time.Millisecond), 10)
+	provider.instanceInfoMu.Lock()
+	provider.instanceInfo = instanceInfo
+	provider.setContributed(contributed)
+	provider.instanceInfoMu.Unlock()
+	return true
+}
+
+func (provider *DefaultInstanceInfoProvider) buildInstanceInfo() (*InstanceInfo, bool) {
+	// the contributors might call remote services, the readers must not wait for them
+	provider.instanceInfoMu.RLock()
+	instanceProperties := provider.resolveInstanceProperties()
+	clientProperties := provider.clientProperties
+	contributors := append([]InstanceInfoContributor(nil), provider.contributors...)
+	idGenerator := provider.idGenerator
+	logger := provider.logger
+	provider.instanceInfoMu.RUnlock()
+
+	port := strconv.Itoa(instanceProperties.NonSecurePort)
+	securePort := strconv.Itoa(instanceProperties.SecurePort)
+
 	instanceInfo := &InstanceInfo{
-		InstanceId:     provider.instanceProperties.InstanceId,
-		AppName:        strings.ToUpper(provider.instanceProperties.ApplicationName),
-		AppGroupName:   provider.instanceProperties.ApplicationGroupName,
-		IpAddr:         provider.instanceProperties.IpAddr,
-		HomePageUrl:    provider.getUrl(false, hostName, port, provider.instanceProperties.HomePageUrl),
-		StatusPageUrl:  provider.getUrl(false, hostName, port, provider.instanceProperties.StatusPageUrl),
-		HealthCheckUrl: provider.getUrl(false, hostName, port, provider.instanceProperties.HealthCheckUrl),
+		InstanceId:   instanceProperties.InstanceId,
+		AppName:      strings.ToUpper(instanceProperties.ApplicationName),
+		AppGroupName: instanceProperties.ApplicationGroupName,
+		IpAddr:       instanceProperties.IpAddr,
 		DataCenterInfo: &DataCenterInfo{
-			Name:  provider.instanceProperties.DataCenterInfo.Name,
-			Class: provider.instanceProperties.DataCenterInfo.Class,
+			Name:  instanceProperties.DataCenterInfo.Name,
+			Class: instanceProperties.DataCenterInfo.Class,
 		},
-		HostName:         provider.instanceProperties.Hostname,
+		HostName:         instanceProperties.Hostname,
 		CountryId:        1,
 		OverriddenStatus: "UNKNOWN",
 		LeaseInfo: &LeaseInfo{
-			RenewalIntervalInSecs: 30,
-			DurationInSecs:        90,
+			RenewalIntervalInSecs: instanceProperties.LeaseRenewalIntervalInSeconds,
+			DurationInSecs:        instanceProperties.LeaseExpirationDurationInSeconds,
 		},
 		LastDirtyTimestamp: "0",
 	}
 
-	instanceInfo.VipAddress = provider.instanceProperties.ApplicationName
+	instanceInfo.VipAddress = instanceProperties.ApplicationName
 	instanceInfo.Port = &PortWrapper{
-		Enabled: strconv.FormatBool(provider.instanceProperties.NonSecurePortEnabled),
-		Port:    provider.instanceProperties.NonSecurePort,
+		Enabled: strconv.FormatBool(instanceProperties.NonSecurePortEnabled),
+		Port:    instanceProperties.NonSecurePort,
 	}
 
-	instanceInfo.SecureVipAddress = provider.instanceProperties.ApplicationName
+	instanceInfo.SecureVipAddress = instanceProperties.ApplicationName
 	instanceInfo.SecurePort = &PortWrapper{
-		Enabled: strconv.FormatBool(provider.instanceProperties.SecurePortEnabled),
-		Port:    provider.instanceProperties.SecurePort,
+		Enabled: strconv.FormatBool(instanceProperties.SecurePortEnabled),
+		Port:    instanceProperties.SecurePort,
 	}
-	instanceInfo.SecureHealthCheckUrl = provider.getUrl(true, hostName, port, provider.instanceProperties.HealthCheckUrl)
 	instanceInfo.Status = InstanceStatusUp
 
-	provider.instanceInfo = instanceInfo
-	return instanceInfo
+	instanceInfo.Metadata = make(map[string]string)
+	for key, value := range instanceProperties.MetadataMap {
+		instanceInfo.Metadata[key] = value
+	}
+	instanceInfo.Metadata[ZoneKey] = clientProperties.GetZone(&instanceProperties)
+
+	contributed := true
+	for _, contributor := range contributors {
+		if err := contributor.Contribute(instanceInfo); err != nil {
+			contributed = false
+			logger.Warning("instance info could not be contributed", Fields{
+				FieldApp: instanceInfo.AppName,
+			}.withError(err))
+		}
+	}
+
+	// the id is generated from what the contributors resolved, e.g. the pod ip
+	if instanceInfo.InstanceId == "" {
+		instanceInfo.InstanceId = idGenerator.Generate(instanceInfo, instanceProperties)
+	}
+
+	hostName := instanceInfo.HostName
+	if hostName == "" {
+		hostName, _ = os.Hostname()
+	}
+	// the pages are advertised on the secure port if the instance cannot be reached without tls
+	if !instanceProperties.NonSecurePortEnabled && instanceProperties.SecurePortEnabled {
+		instanceInfo.HomePageUrl = provider.getUrl(true, hostName, securePort, instanceProperties.HomePageUrl)
+		instanceInfo.StatusPageUrl = provider.getUrl(true, hostName, securePort, instanceProperties.StatusPageUrl)
+		instanceInfo.HealthCheckUrl = provider.getUrl(true, hostName, securePort, instanceProperties.HealthCheckUrl)
+	} else {
+		instanceInfo.HomePageUrl = provider.getUrl(false, hostName, port, instanceProperties.HomePageUrl)
+		instanceInfo.StatusPageUrl = provider.getUrl(false, hostName, port, instanceProperties.StatusPageUrl)
+		instanceInfo.HealthCheckUrl = provider.getUrl(false, hostName, port, instanceProperties.HealthCheckUrl)
+	}
+	if instanceProperties.SecurePortEnabled {
+		instanceInfo.SecureHealthCheckUrl = provider.getUrl(true, hostName, securePort, instanceProperties.HealthCheckUrl)
+	}
+
+	return instanceInfo, contributed
+}
+
+func (provider *DefaultInstanceInfoProvider) resolveInstanceProperties() InstanceProperties {
+	instanceProperties := provider.instanceProperties
+	// there is no other listener to advertise, the server port is the secure one unless it is configured
+	if instanceProperties.HttpsOnly {
+		instanceProperties.NonSecurePortEnabled = false
+		instanceProperties.SecurePortEnabled = true
+		if !instanceProperties.securePortConfigured {
+			instanceProperties.SecurePort = instanceProperties.NonSecurePort
+		}
+	}
+
+	// the port 0 is replaced with the port the listener is bound to, a disabled port is never advertised
+	if instanceProperties.NonSecurePortEnabled && instanceProperties.NonSecurePort == 0 {
+		instanceProperties.NonSecurePort = provider.listenerPort
+	}
+	if instanceProperties.SecurePortEnabled && instanceProperties.SecurePort == 0 {
+		instanceProperties.SecurePort = provider.secureListenerPort
+	}
+	return instanceProperties
+}
+
+func (provider *DefaultInstanceInfoProvider) isPortKnown() bool {
+	instanceProperties := provider.resolveInstanceProperties()
+	return (!instanceProperties.NonSecurePortEnabled || instanceProperties.NonSecurePort > 0) &&
+		(!instanceProperties.SecurePortEnabled || instanceProperties.SecurePort > 0)
 }
 
 func (provider *DefaultInstanceInfoProvider) getUrl(isSecure bool, hostName string, port string, urlPath string) string {
 	scheme := "http"
+	defaultPort := strconv.Itoa(nonSecurePort)
 	if isSecure {
 		scheme = "https"
+		defaultPort = strconv.Itoa(securePort)
 	}
 
 	stringPort := ""
-	if port != "80" && port != "443" {
+	if port != defaultPort {
 		stringPort = ":" + port
 	}
 	host := hostName + stringPort
@@ -0,0 +1,91 @@
+package eureka
+
+import (
+	"testing"
+)
+
+func TestDefaultInstanceInfoProvider_Urls(t *testing.T) {
+	testCases := []struct {
+		name                 string
+		instanceProperties   func(instanceProperties *InstanceProperties)
+		expectedPortEnabled  string
+		expectedSecurePort   int
+		expectedStatusPage   string
+		expectedHealthCheck  string
+		expectedSecureHealth string
+	}{
+		{
+			name:                "non secure port only",
+			expectedPortEnabled: "true",
+			expectedSecurePort:  443,
+			expectedStatusPage:  "http://host-1:8080" + statusPageUrlPath,
+			expectedHealthCheck: "http://host-1:8080" + healthCheckUrlPath,
+		},
+		{
+			name: "both ports",
+			instanceProperties: func(instanceProperties *InstanceProperties) {
+				instanceProperties.SecurePortEnabled = true
+				instanceProperties.SecurePort = 8443
+			},
+			expectedPortEnabled:  "true",
+			expectedSecurePort:   8443,
+			expectedStatusPage:   "http://host-1:8080" + statusPageUrlPath,
+			expectedHealthCheck:  "http://host-1:8080" + healthCheckUrlPath,
+			expectedSecureHealth: "https://host-1:8443" + healthCheckUrlPath,
+		},
+		{
+			name: "https only on the server port",
+			instanceProperties: func(instanceProperties *InstanceProperties) {
+				instanceProperties.HttpsOnly = true
+			},
+			expectedPortEnabled:  "false",
+			expectedSecurePort:   8080,
+			expectedStatusPage:   "https://host-1:8080" + statusPageUrlPath,
+			expectedHealthCheck:  "https://host-1:8080" + healthCheckUrlPath,
+			expectedSecureHealth: "https://host-1:8080" + healthCheckUrlPath,
+		},
+		{
+			name: "https only on the configured secure port",
+			instanceProperties: func(instanceProperties *InstanceProperties) {
+				instanceProperties.HttpsOnly = true
+				instanceProperties.SecurePort = 443
+				instanceProperties.securePortConfigured = true
+			},
+			expectedPortEnabled:  "false",
+			expectedSecurePort:   443,
+			expectedStatusPage:   "https://host-1" + statusPageUrlPath,
+			expectedHealthCheck:  "https://host-1" + healthCheckUrlPath,
+			expectedSecureHealth: "https://host-1" + healthCheckUrlPath,
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			instanceProperties := newValidInstanceProperties()
+			instanceProperties.Hostname = "host-1"
+			instanceProperties.SecurePort = securePort
+			instanceProperties.StatusPageUrl = statusPageUrlPath
+			instanceProperties.HealthCheckUrl = healthCheckUrlPath
+			if testCase.instanceProperties != nil {
+				testCase.instanceProperties(&instanceProperties)
+			}
+
+			instanceInfo := newDefaultInstanceInfoProvider(instanceProperties, *newClientProperties(), nil, nil).GetInstanceInfo()
+			if instanceInfo.Port.Enabled != testCase.expectedPortEnabled {
+				t.Errorf("expected the port enabled to be %s, got %s", testCase.expectedPortEnabled, instanceInfo.Port.Enabled)
+			}
+			if instanceInfo.SecurePort.Port != testCase.expectedSecurePort {
+				t.Errorf("expected the secure port %d, got %d", testCase.expectedSecurePort, instanceInfo.SecurePort.Port)
+			}
+			if instanceInfo.StatusPageUrl != testCase.expectedStatusPage {
+				t.Errorf("expected the status page url %s, got %s", testCase.expectedStatusPage, instanceInfo.StatusPageUrl)
+			}
+			if instanceInfo.HealthCheckUrl != testCase.expectedHealthCheck {
+				t.Errorf("expected the health check url %s, got %s", testCase.expectedHealthCheck, instanceInfo.HealthCheckUrl)
+			}
+			if instanceInfo.SecureHealthCheckUrl != testCase.expectedSecureHealth {
+				t.Errorf("expected the secure health check url %q, got %q", testCase.expectedSecureHealth, instanceInfo.SecureHealthCheckUrl)
+			}
+		})
+	}
+}
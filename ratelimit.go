@@ -0,0 +1,58 @@
+package eureka
+
+import (
+	"sync"
+	"time"
+)
+
+type tokenBucket struct {
+	ratePerSecond float64
+	burst         float64
+	tokens        float64
+	lastRefill    time.Time
+	bucketMu      sync.Mutex
+}
+
+func newTokenBucket(ratePerMinute int, burst int) *tokenBucket {
+	if burst <= 0 {
+		burst = 1
+	}
+	return &tokenBucket{
+		ratePerSecond: float64(ratePerMinute) / 60,
+		burst:         float64(burst),
+		tokens:        float64(burst),
+		lastRefill:    time.Now(),
+	}
+}
+
+func (bucket *tokenBucket) setRate(ratePerMinute int, burst int) {
+	bucket.bucketMu.Lock()
+	defer bucket.bucketMu.Unlock()
+	if burst <= 0 {
+		burst = 1
+	}
+	bucket.ratePerSecond = float64(ratePerMinute) / 60
+	bucket.burst = float64(burst)
+	// the tokens left are kept, a smaller burst only caps them
+	if bucket.tokens > bucket.burst {
+		bucket.tokens = bucket.burst
+	}
+}
+
+func (bucket *tokenBucket) acquire() bool {
+	bucket.bucketMu.Lock()
+	defer bucket.bucketMu.Unlock()
+
+	now := time.Now()
+	bucket.tokens += now.Sub(bucket.lastRefill).Seconds() * bucket.ratePerSecond
+	if bucket.tokens > bucket.burst {
+		bucket.tokens = bucket.burst
+	}
+	bucket.lastRefill = now
+
+	if bucket.tokens < 1 {
+		return false
+	}
+	bucket.tokens--
+	return true
+}
@@ -0,0 +1,69 @@
+package eureka
+
+import (
+	"testing"
+	"time"
+)
+
+func TestTokenBucket_Acquire(t *testing.T) {
+	testCases := []struct {
+		name          string
+		ratePerMinute int
+		burst         int
+		drained       bool
+		elapsed       time.Duration
+		expected      []bool
+	}{
+		{
+			name:          "burst",
+			ratePerMinute: 4,
+			burst:         2,
+			expected:      []bool{true, true, false},
+		},
+		{
+			name:          "zero burst allows one",
+			ratePerMinute: 4,
+			expected:      []bool{true, false},
+		},
+		{
+			name:          "refill",
+			ratePerMinute: 4,
+			burst:         2,
+			drained:       true,
+			elapsed:       15 * time.Second,
+			expected:      []bool{true, false},
+		},
+		{
+			name:          "refill is capped by the burst",
+			ratePerMinute: 4,
+			burst:         2,
+			drained:       true,
+			elapsed:       time.Hour,
+			expected:      []bool{true, true, false},
+		},
+		{
+			name:     "no refill without a rate",
+			burst:    2,
+			drained:  true,
+			elapsed:  time.Hour,
+			expected: []bool{false},
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			bucket := newTokenBucket(testCase.ratePerMinute, testCase.burst)
+			if testCase.drained {
+				bucket.tokens = 0
+			}
+			// the last refill is moved back instead of waiting
+			bucket.lastRefill = bucket.lastRefill.Add(-testCase.elapsed)
+
+			for index, expected := range testCase.expected {
+				if acquired := bucket.acquire(); acquired != expected {
+					t.Errorf("attempt %d: expected %v, got %v", index, expected, acquired)
+				}
+			}
+		})
+	}
+}
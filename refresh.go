@@ -0,0 +1,161 @@
+package eureka
+
+import (
+	context "github.com/procyon-projects/procyon-context"
+	core "github.com/procyon-projects/procyon-core"
+	"strings"
+	"sync"
+)
+
+type PropertiesRefresher struct {
+	httpClient           DefaultHttpClient
+	serviceUrlProvider   *RefreshableServiceUrlProvider
+	instanceInfoProvider *DefaultInstanceInfoProvider
+	registrar            *Registrar
+	replicator           *InstanceInfoReplicator
+	registryCache        *RegistryCache
+	environment          core.Environment
+	logger               Logger
+	refreshMu            sync.Mutex
+}
+
+func newPropertiesRefresher(httpClient DefaultHttpClient,
+	instanceInfoProvider *DefaultInstanceInfoProvider,
+	registrar *Registrar,
+	replicator *InstanceInfoReplicator,
+	registryCache *RegistryCache,
+	environment core.Environment,
+	logger Logger) *PropertiesRefresher {
+	// the service urls resolved from dns are not taken from the properties
+	serviceUrlProvider, _ := httpClient.serviceUrlProvider.(*RefreshableServiceUrlProvider)
+	return &PropertiesRefresher{
+		httpClient:           httpClient,
+		serviceUrlProvider:   serviceUrlProvider,
+		instanceInfoProvider: instanceInfoProvider,
+		registrar:            registrar,
+		replicator:           replicator,
+		registryCache:        registryCache,
+		environment:          environment,
+		logger:               wrapLogger(logger),
+	}
+}
+
+func (refresher *PropertiesRefresher) SetLogger(logger Logger) {
+	refresher.refreshMu.Lock()
+	defer refresher.refreshMu.Unlock()
+	refresher.logger = wrapLogger(logger)
+}
+
+func (refresher *PropertiesRefresher) GetApplicationListenerName() string {
+	return "github.com.procyon.cloud.eureka.propertiesRefresher"
+}
+
+func (refresher *PropertiesRefresher) SubscribeEvents() []context.ApplicationEventId {
+	return []context.ApplicationEventId{
+		context.ApplicationContextRefreshedEventId(),
+	}
+}
+
+func (refresher *PropertiesRefresher) OnApplicationEvent(ctx context.Context, event context.ApplicationEvent) {
+	if event.GetEventId() != context.ApplicationContextRefreshedEventId() {
+		return
+	}
+
+	if err := refresher.Rebind(); err != nil {
+		refresher.getLogger().Error("properties could not be refreshed", Fields{}.withError(err))
+	}
+}
+
+// Rebind binds the properties from the environment again and applies them.
+// The contributors are run outside the instance info lock, so a slow one
+// does not block the readers while the instance info is rebuilt.
+func (refresher *PropertiesRefresher) Rebind() error {
+	if refresher.environment == nil {
+		return nil
+	}
+
+	var typeConverterService core.TypeConverterService
+	if configurableEnvironment, ok := refresher.environment.(core.ConfigurableEnvironment); ok {
+		typeConverterService = configurableEnvironment.GetTypeConverterService()
+	} else {
+		typeConverterService = core.NewDefaultTypeConverterService()
+	}
+	bindingProcessor := context.NewConfigurationPropertiesBindingProcessor(refresher.environment, typeConverterService)
+
+	clientProperties := newClientProperties()
+	if _, err := bindingProcessor.BeforePeaInitialization("clientProperties", clientProperties); err != nil {
+		return err
+	}
+
+	instanceProperties := newInstanceProperties(refresher.environment)
+	if _, err := bindingProcessor.BeforePeaInitialization("instanceProperties", instanceProperties); err != nil {
+		return err
+	}
+
+	return refresher.Refresh(*clientProperties, *instanceProperties)
+}
+
+func (refresher *PropertiesRefresher) getLogger() Logger {
+	refresher.refreshMu.Lock()
+	defer refresher.refreshMu.Unlock()
+	return refresher.logger
+}
+
+func (refresher *PropertiesRefresher) Refresh(clientProperties ClientProperties, instanceProperties InstanceProperties) error {
+	refresher.refreshMu.Lock()
+	defer refresher.refreshMu.Unlock()
+
+	// nothing is applied from invalid properties, the client keeps running with the previous ones
+	if err := validateProperties(clientProperties, instanceProperties); err != nil {
+		return err
+	}
+
+	if refresher.serviceUrlProvider != nil && !clientProperties.UseDnsForFetchingServiceUrls {
+		serviceUrls := clientProperties.GetEurekaServiceUrls(clientProperties.GetZone(&instanceProperties))
+		if refresher.serviceUrlProvider.SetServiceUrls(serviceUrls) {
+			// the connections to the previous servers are not reused
+			refresher.httpClient.RebuildTransport()
+			refresher.logger.Info("service urls changed", Fields{
+				FieldServiceUrl: strings.Join(serviceUrls, ","),
+			})
+		}
+	}
+
+	// the discovery client reads the client properties from the registry cache
+	if refresher.registryCache != nil {
+		refresher.registryCache.SetClientProperties(clientProperties)
+	}
+	if refresher.replicator != nil {
+		refresher.replicator.SetClientProperties(clientProperties)
+	}
+	if refresher.registrar != nil {
+		refresher.registrar.SetTaskOptions(clientProperties.GetHeartbeatTaskOptions())
+	}
+
+	if refresher.instanceInfoProvider == nil {
+		return nil
+	}
+
+	previous := refresher.instanceInfoProvider.GetInstanceInfo()
+	if !refresher.instanceInfoProvider.Refresh(instanceProperties, clientProperties) {
+		return nil
+	}
+
+	instanceInfo := refresher.instanceInfoProvider.GetInstanceInfo()
+	refresher.logger.Info("instance info changed", Fields{
+		FieldApp:        instanceInfo.AppName,
+		FieldInstanceId: instanceInfo.InstanceId,
+	})
+
+	if refresher.registrar == nil {
+		return nil
+	}
+
+	err := refresher.registrar.Reregister()
+	if err != nil || (previous.AppName == instanceInfo.AppName && previous.InstanceId == instanceInfo.InstanceId) {
+		return err
+	}
+
+	// the instance is known by another id now, the previous one would stay until its lease expires
+	return refresher.httpClient.Deregister(previous.AppName, previous.InstanceId)
+}
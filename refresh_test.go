@@ -0,0 +1,101 @@
+package eureka
+
+import (
+	"testing"
+	"time"
+)
+
+func TestPropertiesRefresher_Refresh(t *testing.T) {
+	testCases := []struct {
+		name                       string
+		clientProperties           func(clientProperties *ClientProperties)
+		instanceProperties         func(instanceProperties *InstanceProperties)
+		expectErr                  bool
+		expectedThresholdSeconds   int
+		expectedReplication        time.Duration
+		expectedBurst              float64
+		expectedMaxBackoff         int
+		expectedInstanceIdStrategy string
+	}{
+		{
+			name: "invalid properties are not applied",
+			clientProperties: func(clientProperties *ClientProperties) {
+				clientProperties.FallbackThresholdSeconds = 60
+			},
+			instanceProperties: func(instanceProperties *InstanceProperties) {
+				instanceProperties.InstanceIdStrategy = "unknown"
+			},
+			expectErr:                  true,
+			expectedThresholdSeconds:   300,
+			expectedReplication:        30 * time.Second,
+			expectedBurst:              2,
+			expectedMaxBackoff:         defaultMaxBackoffMultiplier,
+			expectedInstanceIdStrategy: InstanceIdStrategyHostAppPort,
+		},
+		{
+			name: "valid properties are applied to every component",
+			clientProperties: func(clientProperties *ClientProperties) {
+				clientProperties.FallbackThresholdSeconds = 60
+				clientProperties.InstanceInfoReplicationIntervalSeconds = 45
+				clientProperties.OnDemandUpdateBurstSize = 5
+				clientProperties.HeartbeatMaxBackoffMultiplier = 3
+			},
+			instanceProperties: func(instanceProperties *InstanceProperties) {
+				instanceProperties.InstanceIdStrategy = InstanceIdStrategyIp
+			},
+			expectedThresholdSeconds:   60,
+			expectedReplication:        45 * time.Second,
+			expectedBurst:              5,
+			expectedMaxBackoff:         3,
+			expectedInstanceIdStrategy: InstanceIdStrategyIp,
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			clientProperties := newClientProperties()
+			clientProperties.ServiceUrl = map[string]string{DefaultZone: "http://localhost:8761/eureka/"}
+			instanceProperties := newValidInstanceProperties()
+
+			httpClient := newHttpClient(*clientProperties, instanceProperties, nil, nil, nil)
+			provider := newDefaultInstanceInfoProvider(instanceProperties, *clientProperties, nil, nil)
+			manager := newInstanceInfoManager(provider)
+			registryCache := newRegistryCache(httpClient, *clientProperties, nil, nil)
+			registrar := newRegistrar(httpClient, manager, *clientProperties, nil, nil)
+			replicator := newInstanceInfoReplicator(httpClient, manager, registrar, *clientProperties, nil, nil)
+			refresher := newPropertiesRefresher(httpClient, provider, registrar, replicator, registryCache, nil, nil)
+
+			refreshedClientProperties := *clientProperties
+			testCase.clientProperties(&refreshedClientProperties)
+			refreshedInstanceProperties := instanceProperties
+			testCase.instanceProperties(&refreshedInstanceProperties)
+
+			err := refresher.Refresh(refreshedClientProperties, refreshedInstanceProperties)
+			if (err != nil) != testCase.expectErr {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if threshold := registryCache.getClientProperties().FallbackThresholdSeconds; threshold != testCase.expectedThresholdSeconds {
+				t.Errorf("expected the fallback threshold %d, got %d", testCase.expectedThresholdSeconds, threshold)
+			}
+			if interval := replicator.getInterval(); interval != testCase.expectedReplication {
+				t.Errorf("expected the replication interval %s, got %s", testCase.expectedReplication, interval)
+			}
+			if burst := replicator.rateLimiter.burst; burst != testCase.expectedBurst {
+				t.Errorf("expected the on demand update burst %v, got %v", testCase.expectedBurst, burst)
+			}
+			registrar.stateMu.RLock()
+			maxBackoff := registrar.taskOptions.MaxBackoffMultiplier
+			registrar.stateMu.RUnlock()
+			if maxBackoff != testCase.expectedMaxBackoff {
+				t.Errorf("expected the heartbeat max backoff %d, got %d", testCase.expectedMaxBackoff, maxBackoff)
+			}
+			provider.instanceInfoMu.RLock()
+			strategy := provider.instanceProperties.InstanceIdStrategy
+			provider.instanceInfoMu.RUnlock()
+			if strategy != testCase.expectedInstanceIdStrategy {
+				t.Errorf("expected the instance id strategy %s, got %s", testCase.expectedInstanceIdStrategy, strategy)
+			}
+		})
+	}
+}
@@ -0,0 +1,345 @@
+package eureka
+
+import (
+	"context"
+	"math"
+	"net/http"
+	"sync"
+	"time"
+)
+
+const defaultRenewalInterval = 30 * time.Second
+
+var closedChannel = make(chan struct{})
+
+func init() {
+	close(closedChannel)
+}
+
+type Registrar struct {
+	httpClient              ContextHttpClient
+	instanceInfoProvider    InstanceInfoProvider
+	metrics                 MetricsRecorder
+	logger                  Logger
+	registered              bool
+	consecutiveFailures     int
+	lastSuccessfulHeartbeat time.Time
+	stateMu                 sync.RWMutex
+	lifecycleMu             sync.Mutex
+	stopCh                  chan struct{}
+	interval                time.Duration
+	taskOptions             TaskOptions
+}
+
+func newRegistrar(httpClient ContextHttpClient, instanceInfoManager *InstanceInfoManager, clientProperties ClientProperties, logger Logger, metrics MetricsRecorder) *Registrar {
+	registrar := &Registrar{
+		httpClient:           httpClient,
+		instanceInfoProvider: instanceInfoManager,
+		metrics:              NoOpMetricsRecorder{},
+		logger:               NoOpLogger{},
+		taskOptions:          clientProperties.GetHeartbeatTaskOptions(),
+	}
+	registrar.SetLogger(logger)
+	registrar.SetMetricsRecorder(metrics)
+	return registrar
+}
+
+func (registrar *Registrar) SetLogger(logger Logger) {
+	registrar.stateMu.Lock()
+	defer registrar.stateMu.Unlock()
+	registrar.logger = wrapLogger(logger)
+}
+
+func (registrar *Registrar) SetMetricsRecorder(metrics MetricsRecorder) {
+	registrar.stateMu.Lock()
+	defer registrar.stateMu.Unlock()
+	if metrics == nil {
+		metrics = NoOpMetricsRecorder{}
+	}
+	registrar.metrics = metrics
+
+	// read when the metrics are scraped, so the value keeps growing even after the heartbeats stop
+	if gaugeFuncRecorder, ok := metrics.(GaugeFuncRecorder); ok {
+		gaugeFuncRecorder.SetGaugeFunc(MetricSecondsSinceRenewal, registrar.getSecondsSinceRenewal, nil)
+	}
+}
+
+func (registrar *Registrar) Start() error {
+	registrar.lifecycleMu.Lock()
+	defer registrar.lifecycleMu.Unlock()
+	if registrar.stopCh != nil {
+		return nil
+	}
+
+	// an instance with invalid properties is never registered
+	if validatable, ok := registrar.instanceInfoProvider.(Validatable); ok {
+		if err := validatable.Validate(); err != nil {
+			registrar.getLogger().Error("instance could not be registered", Fields{}.withError(err))
+			return err
+		}
+	}
+
+	registrar.stopCh = make(chan struct{})
+	select {
+	case <-registrar.getPortReady():
+		return registrar.startHeartbeats(registrar.stopCh)
+	default:
+		registrar.getLogger().Info("registration is held until the server port is known", Fields{})
+		go registrar.awaitPort(registrar.stopCh)
+		return nil
+	}
+}
+
+func (registrar *Registrar) awaitPort(stopCh chan struct{}) {
+	select {
+	case <-stopCh:
+		return
+	case <-registrar.getPortReady():
+	}
+
+	registrar.lifecycleMu.Lock()
+	defer registrar.lifecycleMu.Unlock()
+	// stopped while waiting for the port
+	if registrar.stopCh != stopCh {
+		return
+	}
+	_ = registrar.startHeartbeats(stopCh)
+}
+
+func (registrar *Registrar) startHeartbeats(stopCh chan struct{}) error {
+	// heartbeats keep trying to register if the initial registration fails
+	err := registrar.register(context.Background(), "instance registered")
+
+	registrar.interval = registrar.getRenewalInterval()
+	go registrar.run(stopCh, registrar.interval)
+	return err
+}
+
+func (registrar *Registrar) getPortReady() <-chan struct{} {
+	if portReady, ok := registrar.instanceInfoProvider.(interface{ PortReady() <-chan struct{} }); ok {
+		return portReady.PortReady()
+	}
+	return closedChannel
+}
+
+func (registrar *Registrar) Reregister() error {
+	registrar.lifecycleMu.Lock()
+	defer registrar.lifecycleMu.Unlock()
+	// not started yet or the registration is still held until the port is known
+	if registrar.stopCh == nil || registrar.interval == 0 {
+		return nil
+	}
+
+	// registering an already known instance again only replaces its info, the lease is kept
+	err := registrar.register(context.Background(), "instance re-registered")
+
+	if interval := registrar.getRenewalInterval(); interval != registrar.interval {
+		close(registrar.stopCh)
+		registrar.interval = interval
+		registrar.stopCh = make(chan struct{})
+		go registrar.run(registrar.stopCh, interval)
+	}
+	return err
+}
+
+func (registrar *Registrar) getRenewalInterval() time.Duration {
+	instanceInfo := registrar.instanceInfoProvider.GetInstanceInfo()
+	if instanceInfo.LeaseInfo != nil && instanceInfo.LeaseInfo.RenewalIntervalInSecs > 0 {
+		return time.Duration(instanceInfo.LeaseInfo.RenewalIntervalInSecs) * time.Second
+	}
+	return defaultRenewalInterval
+}
+
+func (registrar *Registrar) Stop() error {
+	registrar.lifecycleMu.Lock()
+	defer registrar.lifecycleMu.Unlock()
+	if registrar.stopCh == nil {
+		return nil
+	}
+	close(registrar.stopCh)
+	registrar.stopCh = nil
+	registrar.interval = 0
+
+	if !registrar.IsRegistered() {
+		return nil
+	}
+
+	instanceInfo := registrar.instanceInfoProvider.GetInstanceInfo()
+	err := registrar.httpClient.Deregister(instanceInfo.AppName, instanceInfo.InstanceId)
+	if err != nil {
+		registrar.getLogger().Error("instance could not be deregistered", registrar.fields(instanceInfo).withError(err))
+		return err
+	}
+
+	registrar.setRegistered(false)
+	registrar.getLogger().Info("instance deregistered", registrar.fields(instanceInfo))
+	return nil
+}
+
+func (registrar *Registrar) SetTaskOptions(taskOptions TaskOptions) {
+	registrar.lifecycleMu.Lock()
+	defer registrar.lifecycleMu.Unlock()
+
+	registrar.stateMu.Lock()
+	changed := registrar.taskOptions != taskOptions
+	registrar.taskOptions = taskOptions
+	registrar.stateMu.Unlock()
+
+	// the options are read when the heartbeat task is created, a running one is replaced
+	if changed && registrar.stopCh != nil && registrar.interval != 0 {
+		close(registrar.stopCh)
+		registrar.stopCh = make(chan struct{})
+		go registrar.run(registrar.stopCh, registrar.interval)
+	}
+}
+
+func (registrar *Registrar) run(stopCh chan struct{}, interval time.Duration) {
+	registrar.stateMu.RLock()
+	task := newSupervisedTask(TaskHeartbeat, registrar.renew, interval, registrar.taskOptions, registrar.metrics, registrar.logger)
+	registrar.stateMu.RUnlock()
+	task.run(stopCh)
+}
+
+func (registrar *Registrar) Renew() error {
+	return registrar.renew(context.Background())
+}
+
+func (registrar *Registrar) renew(ctx context.Context) error {
+	if !registrar.IsRegistered() {
+		return registrar.register(ctx, "instance registered")
+	}
+
+	instanceInfo := registrar.instanceInfoProvider.GetInstanceInfo()
+	err := registrar.httpClient.SendHeartBeatContext(ctx, instanceInfo.AppName, instanceInfo.InstanceId, instanceInfo, instanceInfo.OverriddenStatus)
+
+	// the server does not know the instance anymore, so it has to be registered again
+	if responseError, ok := err.(*ResponseError); ok && responseError.StatusCode == http.StatusNotFound {
+		registrar.setRegistered(false)
+		registrar.getLogger().Warning("instance evicted by the server", registrar.fields(instanceInfo))
+		registrar.reportHeartbeat(ResultFailure)
+
+		return registrar.register(ctx, "instance re-registered")
+	}
+
+	registrar.stateMu.Lock()
+	result := ResultSuccess
+	if err != nil {
+		result = ResultFailure
+		registrar.consecutiveFailures++
+	} else {
+		registrar.lastSuccessfulHeartbeat = time.Now()
+		registrar.consecutiveFailures = 0
+	}
+	logger := registrar.logger
+	attempt := registrar.consecutiveFailures
+	registrar.stateMu.Unlock()
+
+	if err != nil {
+		logger.Error("instance could not be renewed", registrar.fields(instanceInfo).with(FieldAttempt, attempt).withError(err))
+	} else {
+		logger.Debug("instance renewed", registrar.fields(instanceInfo))
+	}
+
+	registrar.reportHeartbeat(result)
+	return err
+}
+
+func (registrar *Registrar) reportHeartbeat(result string) {
+	registrar.stateMu.RLock()
+	metrics := registrar.metrics
+	lastSuccessfulHeartbeat := registrar.lastSuccessfulHeartbeat
+	registrar.stateMu.RUnlock()
+
+	metrics.IncrementCounter(MetricHeartbeats, map[string]string{
+		LabelResult: result,
+	})
+
+	// the recorders without gauge functions only see the value at the last heartbeat
+	if _, ok := metrics.(GaugeFuncRecorder); !ok && !lastSuccessfulHeartbeat.IsZero() {
+		metrics.SetGauge(MetricSecondsSinceRenewal, time.Since(lastSuccessfulHeartbeat).Seconds(), nil)
+	}
+}
+
+func (registrar *Registrar) getSecondsSinceRenewal() float64 {
+	lastSuccessfulHeartbeat := registrar.GetLastSuccessfulHeartbeat()
+	if lastSuccessfulHeartbeat.IsZero() {
+		return math.NaN()
+	}
+	return time.Since(lastSuccessfulHeartbeat).Seconds()
+}
+
+func (registrar *Registrar) SetStatus(status InstanceStatus) error {
+	instanceInfo := registrar.instanceInfoProvider.GetInstanceInfo()
+	return registrar.httpClient.UpdateStatus(instanceInfo.AppName, instanceInfo.InstanceId, status, instanceInfo)
+}
+
+func (registrar *Registrar) GetStatus() (InstanceStatus, error) {
+	instanceInfo := registrar.instanceInfoProvider.GetInstanceInfo()
+	remoteInstanceInfo, err := registrar.httpClient.GetInstanceByAppNameAndInstanceId(instanceInfo.AppName, instanceInfo.InstanceId)
+	if err != nil {
+		return InstanceStatusUnknown, err
+	}
+	return remoteInstanceInfo.Status, nil
+}
+
+func (registrar *Registrar) IsRegistered() bool {
+	registrar.stateMu.RLock()
+	defer registrar.stateMu.RUnlock()
+	return registrar.registered
+}
+
+func (registrar *Registrar) GetLastSuccessfulHeartbeat() time.Time {
+	registrar.stateMu.RLock()
+	defer registrar.stateMu.RUnlock()
+	return registrar.lastSuccessfulHeartbeat
+}
+
+func (registrar *Registrar) GetConsecutiveFailures() int {
+	registrar.stateMu.RLock()
+	defer registrar.stateMu.RUnlock()
+	return registrar.consecutiveFailures
+}
+
+func (registrar *Registrar) register(ctx context.Context, message string) error {
+	instanceInfo := registrar.instanceInfoProvider.GetInstanceInfo()
+	err := registrar.httpClient.RegisterContext(ctx, instanceInfo)
+
+	registrar.stateMu.Lock()
+	if err != nil {
+		registrar.consecutiveFailures++
+	} else {
+		registrar.registered = true
+		registrar.lastSuccessfulHeartbeat = time.Now()
+		registrar.consecutiveFailures = 0
+	}
+	logger := registrar.logger
+	attempt := registrar.consecutiveFailures
+	registrar.stateMu.Unlock()
+
+	if err != nil {
+		logger.Error("instance could not be registered", registrar.fields(instanceInfo).with(FieldAttempt, attempt).withError(err))
+		return err
+	}
+
+	logger.Info(message, registrar.fields(instanceInfo))
+	return nil
+}
+
+func (registrar *Registrar) getLogger() Logger {
+	registrar.stateMu.RLock()
+	defer registrar.stateMu.RUnlock()
+	return registrar.logger
+}
+
+func (registrar *Registrar) fields(instanceInfo *InstanceInfo) Fields {
+	return Fields{
+		FieldApp:        instanceInfo.AppName,
+		FieldInstanceId: instanceInfo.InstanceId,
+	}
+}
+
+func (registrar *Registrar) setRegistered(registered bool) {
+	registrar.stateMu.Lock()
+	defer registrar.stateMu.Unlock()
+	registrar.registered = registered
+}
@@ -0,0 +1,120 @@
+package eureka
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+type registrationRecorder struct {
+	ports   []int
+	portsMu sync.Mutex
+}
+
+func (recorder *registrationRecorder) ServeHTTP(writer http.ResponseWriter, request *http.Request) {
+	if request.Method == http.MethodPost {
+		instanceResource := &InstanceResource{}
+		if err := json.NewDecoder(request.Body).Decode(instanceResource); err == nil && instanceResource.InstanceInfo != nil {
+			recorder.portsMu.Lock()
+			recorder.ports = append(recorder.ports, instanceResource.InstanceInfo.Port.Port)
+			recorder.portsMu.Unlock()
+		}
+		writer.WriteHeader(http.StatusNoContent)
+		return
+	}
+	writer.WriteHeader(http.StatusOK)
+}
+
+func (recorder *registrationRecorder) getPorts() []int {
+	recorder.portsMu.Lock()
+	defer recorder.portsMu.Unlock()
+	return append([]int(nil), recorder.ports...)
+}
+
+func TestRegistrar_HoldsRegistrationUntilThePortIsKnown(t *testing.T) {
+	recorder := &registrationRecorder{}
+	server := httptest.NewServer(recorder)
+	defer server.Close()
+
+	instanceProperties := newValidInstanceProperties()
+	instanceProperties.NonSecurePort = 0
+	clientProperties := newClientProperties()
+
+	provider := newDefaultInstanceInfoProvider(instanceProperties, *clientProperties, nil, nil)
+	registrar := newRegistrar(NewDefaultHttpClient(server.URL+"/eureka/"), newInstanceInfoManager(provider), *clientProperties, nil, nil)
+	if err := registrar.Start(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer func() {
+		_ = registrar.Stop()
+	}()
+
+	testCases := []struct {
+		name          string
+		port          int
+		secure        bool
+		expectErr     bool
+		expectedPorts []int
+	}{
+		{
+			name:      "zero port is rejected",
+			port:      0,
+			expectErr: true,
+		},
+		{
+			name:      "negative port is rejected",
+			port:      -1,
+			expectErr: true,
+		},
+		{
+			name:   "secure listener does not fill the non secure port",
+			port:   8443,
+			secure: true,
+		},
+		{
+			name:          "non secure listener releases the registration",
+			port:          8081,
+			expectedPorts: []int{8081},
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			err := provider.SetPort(testCase.port, testCase.secure)
+			if (err != nil) != testCase.expectErr {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			deadline := time.Now().Add(time.Second)
+			for len(recorder.getPorts()) < len(testCase.expectedPorts) && time.Now().Before(deadline) {
+				time.Sleep(5 * time.Millisecond)
+			}
+			// gives a registration which must not happen the time to show up
+			if len(testCase.expectedPorts) == 0 {
+				time.Sleep(50 * time.Millisecond)
+			}
+
+			ports := recorder.getPorts()
+			if len(ports) != len(testCase.expectedPorts) {
+				t.Fatalf("expected the registrations %v, got %v", testCase.expectedPorts, ports)
+			}
+			for index := range ports {
+				if ports[index] != testCase.expectedPorts[index] {
+					t.Errorf("expected the registrations %v, got %v", testCase.expectedPorts, ports)
+				}
+			}
+		})
+	}
+
+	// the server has received the registration, the response might still be on its way
+	deadline := time.Now().Add(time.Second)
+	for !registrar.IsRegistered() && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	if !registrar.IsRegistered() {
+		t.Errorf("expected the instance to be registered")
+	}
+}
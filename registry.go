@@ -3,20 +3,40 @@ package eureka
 import cloud "github.com/procyon-projects/procyon-cloud"
 
 type ServiceRegistry struct {
+	registrar  *Registrar
+	replicator *InstanceInfoReplicator
 }
 
-func (serviceRegistry ServiceRegistry) Register(instance cloud.ServiceInstance) {
+func newServiceRegistry(registrar *Registrar, replicator *InstanceInfoReplicator) ServiceRegistry {
+	return ServiceRegistry{
+		registrar,
+		replicator,
+	}
+}
 
+func (serviceRegistry ServiceRegistry) Register(instance cloud.ServiceInstance) {
+	_ = serviceRegistry.registrar.Start()
+	if serviceRegistry.replicator != nil {
+		serviceRegistry.replicator.Start()
+	}
 }
 
 func (serviceRegistry ServiceRegistry) Deregister(instance cloud.ServiceInstance) {
-
+	// stopped first, so a status or metadata change cannot register the instance again
+	if serviceRegistry.replicator != nil {
+		serviceRegistry.replicator.Stop()
+	}
+	_ = serviceRegistry.registrar.Stop()
 }
 
 func (serviceRegistry ServiceRegistry) SetStatus(instance cloud.ServiceInstance, status string) {
-
+	_ = serviceRegistry.registrar.SetStatus(InstanceStatus(status))
 }
 
 func (serviceRegistry ServiceRegistry) GetStatus(instance cloud.ServiceInstance) interface{} {
-	return nil
+	status, err := serviceRegistry.registrar.GetStatus()
+	if err != nil {
+		return nil
+	}
+	return status
 }
@@ -0,0 +1,211 @@
+package eureka
+
+import (
+	"sync"
+	"time"
+)
+
+const defaultReplicationInterval = 30 * time.Second
+
+type InstanceInfoReplicator struct {
+	httpClient          HttpClient
+	instanceInfoManager *InstanceInfoManager
+	registrar           *Registrar
+	interval            time.Duration
+	rateLimiter         *tokenBucket
+	triggerCh           chan struct{}
+	metrics             MetricsRecorder
+	logger              Logger
+	stateMu             sync.RWMutex
+	replicationMu       sync.Mutex
+	lifecycleMu         sync.Mutex
+	stopCh              chan struct{}
+}
+
+func newInstanceInfoReplicator(httpClient HttpClient, instanceInfoManager *InstanceInfoManager, registrar *Registrar, clientProperties ClientProperties, logger Logger, metrics MetricsRecorder) *InstanceInfoReplicator {
+	replicator := &InstanceInfoReplicator{
+		httpClient:          httpClient,
+		instanceInfoManager: instanceInfoManager,
+		registrar:           registrar,
+		interval:            getReplicationInterval(clientProperties),
+		rateLimiter:         newTokenBucket(clientProperties.OnDemandUpdateRatePerMinute, clientProperties.OnDemandUpdateBurstSize),
+		triggerCh:           make(chan struct{}, 1),
+		metrics:             NoOpMetricsRecorder{},
+		logger:              NoOpLogger{},
+	}
+
+	if clientProperties.OnDemandUpdateStatusChange {
+		instanceInfoManager.AddEventListener(replicator)
+	}
+	replicator.SetLogger(logger)
+	replicator.SetMetricsRecorder(metrics)
+	return replicator
+}
+
+func getReplicationInterval(clientProperties ClientProperties) time.Duration {
+	interval := time.Duration(clientProperties.InstanceInfoReplicationIntervalSeconds) * time.Second
+	if interval <= 0 {
+		return defaultReplicationInterval
+	}
+	return interval
+}
+
+// SetClientProperties applies the refreshed replication interval and on demand update rate,
+// the interval is used from the next replication on.
+func (replicator *InstanceInfoReplicator) SetClientProperties(clientProperties ClientProperties) {
+	replicator.rateLimiter.setRate(clientProperties.OnDemandUpdateRatePerMinute, clientProperties.OnDemandUpdateBurstSize)
+
+	replicator.stateMu.Lock()
+	defer replicator.stateMu.Unlock()
+	replicator.interval = getReplicationInterval(clientProperties)
+}
+
+func (replicator *InstanceInfoReplicator) getInterval() time.Duration {
+	replicator.stateMu.RLock()
+	defer replicator.stateMu.RUnlock()
+	return replicator.interval
+}
+
+func (replicator *InstanceInfoReplicator) SetLogger(logger Logger) {
+	replicator.stateMu.Lock()
+	defer replicator.stateMu.Unlock()
+	replicator.logger = wrapLogger(logger)
+}
+
+func (replicator *InstanceInfoReplicator) SetMetricsRecorder(metrics MetricsRecorder) {
+	replicator.stateMu.Lock()
+	defer replicator.stateMu.Unlock()
+	if metrics == nil {
+		metrics = NoOpMetricsRecorder{}
+	}
+	replicator.metrics = metrics
+}
+
+func (replicator *InstanceInfoReplicator) Start() {
+	replicator.lifecycleMu.Lock()
+	defer replicator.lifecycleMu.Unlock()
+	if replicator.stopCh != nil {
+		return
+	}
+	replicator.stopCh = make(chan struct{})
+	go replicator.run(replicator.stopCh)
+}
+
+func (replicator *InstanceInfoReplicator) Stop() {
+	replicator.lifecycleMu.Lock()
+	defer replicator.lifecycleMu.Unlock()
+	if replicator.stopCh != nil {
+		close(replicator.stopCh)
+		replicator.stopCh = nil
+	}
+
+	// a replication in flight must not register the instance again once the caller deregisters it
+	replicator.replicationMu.Lock()
+	defer replicator.replicationMu.Unlock()
+}
+
+func (replicator *InstanceInfoReplicator) OnEvent(event Event) {
+	switch event.(type) {
+	case StatusChangedEvent, MetadataChangedEvent:
+		replicator.OnDemandUpdate()
+	}
+}
+
+func (replicator *InstanceInfoReplicator) OnDemandUpdate() bool {
+	// an update is already waiting, it will carry this change as well
+	if len(replicator.triggerCh) != 0 {
+		return true
+	}
+
+	if !replicator.rateLimiter.acquire() {
+		replicator.stateMu.RLock()
+		metrics := replicator.metrics
+		logger := replicator.logger
+		replicator.stateMu.RUnlock()
+
+		metrics.IncrementCounter(MetricReplications, map[string]string{
+			LabelResult: ResultRateLimited,
+		})
+		// the change stays dirty, the periodic replication pushes it later
+		logger.Debug("on demand update is rate limited", Fields{})
+		return false
+	}
+
+	select {
+	case replicator.triggerCh <- struct{}{}:
+	default:
+	}
+	return true
+}
+
+func (replicator *InstanceInfoReplicator) run(stopCh chan struct{}) {
+	timer := time.NewTimer(replicator.getInterval())
+	defer timer.Stop()
+	for {
+		select {
+		case <-stopCh:
+			return
+		case <-replicator.triggerCh:
+			_ = replicator.Replicate()
+		case <-timer.C:
+			_ = replicator.Replicate()
+		}
+
+		// the next periodic replication is counted from the last one, on demand or not
+		if !timer.Stop() {
+			select {
+			case <-timer.C:
+			default:
+			}
+		}
+		timer.Reset(replicator.getInterval())
+	}
+}
+
+func (replicator *InstanceInfoReplicator) Replicate() error {
+	replicator.replicationMu.Lock()
+	defer replicator.replicationMu.Unlock()
+
+	instanceInfo, lastDirtyTimestamp, dirty := replicator.instanceInfoManager.getDirtyInstanceInfo()
+	if !dirty {
+		return nil
+	}
+
+	replicator.stateMu.RLock()
+	metrics := replicator.metrics
+	logger := replicator.logger
+	replicator.stateMu.RUnlock()
+
+	fields := Fields{
+		FieldApp:        instanceInfo.AppName,
+		FieldInstanceId: instanceInfo.InstanceId,
+	}
+
+	// the change stays dirty, the registrar sends the latest info once it registers the instance
+	select {
+	case <-replicator.instanceInfoManager.PortReady():
+	default:
+		logger.Debug("replication is held until the server port is known", fields)
+		return nil
+	}
+	if replicator.registrar != nil && !replicator.registrar.IsRegistered() {
+		logger.Debug("replication is held until the instance is registered", fields)
+		return nil
+	}
+
+	err := replicator.httpClient.Register(instanceInfo)
+	if err != nil {
+		metrics.IncrementCounter(MetricReplications, map[string]string{
+			LabelResult: ResultFailure,
+		})
+		logger.Warning("instance info could not be replicated", fields.withError(err))
+		return err
+	}
+
+	replicator.instanceInfoManager.unsetDirty(lastDirtyTimestamp)
+	metrics.IncrementCounter(MetricReplications, map[string]string{
+		LabelResult: ResultSuccess,
+	})
+	logger.Debug("instance info replicated", fields)
+	return nil
+}
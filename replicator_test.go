@@ -0,0 +1,230 @@
+package eureka
+
+import (
+	"testing"
+	"time"
+)
+
+func TestInstanceInfoReplicator_Replicate(t *testing.T) {
+	testCases := []struct {
+		name                  string
+		port                  int
+		prepare               func(registrar *Registrar, replicator *InstanceInfoReplicator) error
+		expectedRegistrations []InstanceStatus
+		expectDirty           bool
+	}{
+		{
+			name:        "port is not known",
+			port:        0,
+			expectDirty: true,
+		},
+		{
+			name:        "instance is not registered",
+			port:        8080,
+			expectDirty: true,
+		},
+		{
+			name: "instance is registered",
+			port: 8080,
+			prepare: func(registrar *Registrar, replicator *InstanceInfoReplicator) error {
+				return registrar.Renew()
+			},
+			expectedRegistrations: []InstanceStatus{InstanceStatusUp, InstanceStatusStarting},
+		},
+		{
+			name: "instance is deregistered",
+			port: 8080,
+			prepare: func(registrar *Registrar, replicator *InstanceInfoReplicator) error {
+				if err := registrar.Start(); err != nil {
+					return err
+				}
+				newServiceRegistry(registrar, replicator).Deregister(nil)
+				return nil
+			},
+			expectedRegistrations: []InstanceStatus{InstanceStatusUp},
+			expectDirty:           true,
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			server := newFakeEurekaServer(&Applications{})
+			defer server.Close()
+
+			clientProperties := newClientProperties()
+			instanceProperties := newValidInstanceProperties()
+			instanceProperties.NonSecurePort = testCase.port
+
+			httpClient := NewDefaultHttpClient(server.serviceUrl())
+			provider := newDefaultInstanceInfoProvider(instanceProperties, *clientProperties, nil, nil)
+			manager := newInstanceInfoManager(provider)
+			registrar := newRegistrar(httpClient, manager, *clientProperties, nil, nil)
+			replicator := newInstanceInfoReplicator(httpClient, manager, registrar, *clientProperties, nil, nil)
+			defer func() {
+				_ = registrar.Stop()
+			}()
+
+			if testCase.prepare != nil {
+				if err := testCase.prepare(registrar, replicator); err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+			}
+
+			manager.SetStatus(InstanceStatusStarting)
+			if err := replicator.Replicate(); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			registrations := server.getRegistrations()
+			if len(registrations) != len(testCase.expectedRegistrations) {
+				t.Fatalf("expected %d registrations, got %d", len(testCase.expectedRegistrations), len(registrations))
+			}
+			for index, registration := range registrations {
+				if registration.Status != testCase.expectedRegistrations[index] {
+					t.Errorf("expected the registered status %s, got %s", testCase.expectedRegistrations[index], registration.Status)
+				}
+				if registration.Port.Port == 0 {
+					t.Errorf("expected the port to be known when the instance is registered")
+				}
+			}
+			if manager.IsDirty() != testCase.expectDirty {
+				t.Errorf("expected the dirty flag %v, got %v", testCase.expectDirty, manager.IsDirty())
+			}
+		})
+	}
+}
+
+func TestInstanceInfoReplicator_OnDemandUpdate(t *testing.T) {
+	testCases := []struct {
+		name            string
+		burst           int
+		replicate       bool
+		expected        []bool
+		expectedPending int
+	}{
+		{
+			name:            "coalesced while an update is waiting",
+			burst:           2,
+			expected:        []bool{true, true, true},
+			expectedPending: 1,
+		},
+		{
+			name:      "within the burst",
+			burst:     2,
+			replicate: true,
+			expected:  []bool{true, true, false},
+		},
+		{
+			name:      "rate limited",
+			burst:     1,
+			replicate: true,
+			expected:  []bool{true, false},
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			clientProperties := newClientProperties()
+			clientProperties.OnDemandUpdateBurstSize = testCase.burst
+			provider := newDefaultInstanceInfoProvider(newValidInstanceProperties(), *clientProperties, nil, nil)
+			replicator := newInstanceInfoReplicator(nil, newInstanceInfoManager(provider), nil, *clientProperties, nil, nil)
+
+			for index, expected := range testCase.expected {
+				if accepted := replicator.OnDemandUpdate(); accepted != expected {
+					t.Errorf("update %d: expected %v, got %v", index, expected, accepted)
+				}
+				// takes the update the way the replication loop does
+				if testCase.replicate {
+					select {
+					case <-replicator.triggerCh:
+					default:
+					}
+				}
+			}
+
+			if pending := len(replicator.triggerCh); pending != testCase.expectedPending {
+				t.Errorf("expected %d pending updates, got %d", testCase.expectedPending, pending)
+			}
+		})
+	}
+}
+
+func TestInstanceInfoReplicator_HoldsOnDemandUpdateUntilThePortIsKnown(t *testing.T) {
+	server := newFakeEurekaServer(&Applications{})
+	defer server.Close()
+
+	clientProperties := newClientProperties()
+	instanceProperties := newValidInstanceProperties()
+	instanceProperties.NonSecurePort = 0
+
+	httpClient := NewDefaultHttpClient(server.serviceUrl())
+	provider := newDefaultInstanceInfoProvider(instanceProperties, *clientProperties, nil, nil)
+	manager := newInstanceInfoManager(provider)
+	registrar := newRegistrar(httpClient, manager, *clientProperties, nil, nil)
+	replicator := newInstanceInfoReplicator(httpClient, manager, registrar, *clientProperties, nil, nil)
+	if err := registrar.Start(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	replicator.Start()
+	defer func() {
+		replicator.Stop()
+		_ = registrar.Stop()
+	}()
+
+	awaitRegistrations := func(count int) []InstanceInfo {
+		deadline := time.Now().Add(time.Second)
+		for len(server.getRegistrations()) < count && time.Now().Before(deadline) {
+			time.Sleep(5 * time.Millisecond)
+		}
+		// gives a registration which must not happen the time to show up
+		time.Sleep(50 * time.Millisecond)
+		return server.getRegistrations()
+	}
+
+	testCases := []struct {
+		name           string
+		change         func() error
+		expectedStatus []InstanceStatus
+	}{
+		{
+			name: "status change with the port 0",
+			change: func() error {
+				manager.SetStatus(InstanceStatusStarting)
+				return nil
+			},
+		},
+		{
+			name: "listener port is known",
+			change: func() error {
+				return provider.SetPort(8081, false)
+			},
+			expectedStatus: []InstanceStatus{InstanceStatusStarting},
+		},
+		{
+			name: "status change with the known port",
+			change: func() error {
+				manager.SetStatus(InstanceStatusUp)
+				return nil
+			},
+			expectedStatus: []InstanceStatus{InstanceStatusStarting, InstanceStatusUp},
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			if err := testCase.change(); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			registrations := awaitRegistrations(len(testCase.expectedStatus))
+			if len(registrations) != len(testCase.expectedStatus) {
+				t.Fatalf("expected %d registrations, got %d", len(testCase.expectedStatus), len(registrations))
+			}
+			for index, registration := range registrations {
+				if registration.Port.Port != 8081 || registration.Status != testCase.expectedStatus[index] {
+					t.Errorf("expected %s on the port 8081, got %s on the port %d", testCase.expectedStatus[index], registration.Status, registration.Port.Port)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,115 @@
+package eureka
+
+import "time"
+
+type ClientStats struct {
+	Registered                   bool       `json:"registered"`
+	LastSuccessfulHeartbeat      *time.Time `json:"lastSuccessfulHeartbeat,omitempty"`
+	ConsecutiveHeartbeatFailures int        `json:"consecutiveHeartbeatFailures"`
+	LastFetchTime                *time.Time `json:"lastFetchTime,omitempty"`
+	ConsecutiveFetchFailures     int        `json:"consecutiveFetchFailures"`
+	Stale                        bool       `json:"stale"`
+}
+
+func (stats ClientStats) GetTimeSinceLastHeartbeat() (time.Duration, bool) {
+	if stats.LastSuccessfulHeartbeat == nil {
+		return 0, false
+	}
+	return time.Since(*stats.LastSuccessfulHeartbeat), true
+}
+
+func (stats ClientStats) GetTimeSinceLastFetch() (time.Duration, bool) {
+	if stats.LastFetchTime == nil {
+		return 0, false
+	}
+	return time.Since(*stats.LastFetchTime), true
+}
+
+type ClientStatsProvider struct {
+	registrar          *Registrar
+	registryCache      *RegistryCache
+	registryWithEureka bool
+	fetchRegistry      bool
+}
+
+func newClientStatsProvider(registrar *Registrar, registryCache *RegistryCache, clientProperties ClientProperties) ClientStatsProvider {
+	return ClientStatsProvider{
+		registrar,
+		registryCache,
+		clientProperties.RegistryWithEureka,
+		clientProperties.FetchRegistry,
+	}
+}
+
+func (statsProvider ClientStatsProvider) GetClientStats() ClientStats {
+	stats := ClientStats{}
+
+	if statsProvider.registrar != nil {
+		stats.Registered = statsProvider.registrar.IsRegistered()
+		stats.ConsecutiveHeartbeatFailures = statsProvider.registrar.GetConsecutiveFailures()
+		if lastHeartbeat := statsProvider.registrar.GetLastSuccessfulHeartbeat(); !lastHeartbeat.IsZero() {
+			stats.LastSuccessfulHeartbeat = &lastHeartbeat
+		}
+	}
+
+	if statsProvider.registryCache != nil {
+		stats.ConsecutiveFetchFailures = statsProvider.registryCache.GetConsecutiveFailures()
+		stats.Stale = statsProvider.registryCache.IsStale()
+		if lastFetchTime := statsProvider.registryCache.GetLastFetchTime(); !lastFetchTime.IsZero() {
+			stats.LastFetchTime = &lastFetchTime
+		}
+	}
+	return stats
+}
+
+type ClientStatsHealthIndicator struct {
+	statsProvider      ClientStatsProvider
+	heartbeatThreshold time.Duration
+	fetchThreshold     time.Duration
+}
+
+func NewClientStatsHealthIndicator(statsProvider ClientStatsProvider, heartbeatThreshold, fetchThreshold time.Duration) ClientStatsHealthIndicator {
+	return ClientStatsHealthIndicator{
+		statsProvider,
+		heartbeatThreshold,
+		fetchThreshold,
+	}
+}
+
+func (healthIndicator ClientStatsHealthIndicator) GetName() string {
+	return "eurekaClient"
+}
+
+func (healthIndicator ClientStatsHealthIndicator) GetHealth() Health {
+	if healthIndicator.statsProvider.registrar == nil && healthIndicator.statsProvider.registryCache == nil {
+		return Health{
+			Status: InstanceStatusUnknown,
+		}
+	}
+
+	stats := healthIndicator.statsProvider.GetClientStats()
+	health := Health{
+		Status: InstanceStatusUp,
+		Details: map[string]interface{}{
+			"stats": stats,
+		},
+	}
+
+	// a zero threshold leaves the check out, so does a part the client does not start
+	if healthIndicator.statsProvider.registrar != nil && healthIndicator.statsProvider.registryWithEureka &&
+		healthIndicator.heartbeatThreshold > 0 {
+		if sinceLastHeartbeat, ok := stats.GetTimeSinceLastHeartbeat(); !ok || sinceLastHeartbeat > healthIndicator.heartbeatThreshold {
+			health.Status = InstanceStatusDown
+			health.Details["heartbeat"] = "no successful heartbeat within " + healthIndicator.heartbeatThreshold.String()
+		}
+	}
+
+	if healthIndicator.statsProvider.registryCache != nil && healthIndicator.statsProvider.fetchRegistry &&
+		healthIndicator.fetchThreshold > 0 {
+		if sinceLastFetch, ok := stats.GetTimeSinceLastFetch(); !ok || sinceLastFetch > healthIndicator.fetchThreshold {
+			health.Status = InstanceStatusDown
+			health.Details["registry"] = "no successful registry fetch within " + healthIndicator.fetchThreshold.String()
+		}
+	}
+	return health
+}
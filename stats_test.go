@@ -0,0 +1,160 @@
+package eureka
+
+import (
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestClientStatsProvider_GetClientStats(t *testing.T) {
+	testCases := []struct {
+		name                      string
+		statusCode                int
+		expectRegistered          bool
+		expectHeartbeat           bool
+		expectFetch               bool
+		expectedHeartbeatFailures int
+		expectedFetchFailures     int
+	}{
+		{
+			name:             "successful heartbeat and fetch",
+			expectRegistered: true,
+			expectHeartbeat:  true,
+			expectFetch:      true,
+		},
+		{
+			name:                      "failed heartbeat and fetch",
+			statusCode:                http.StatusInternalServerError,
+			expectedHeartbeatFailures: 1,
+			expectedFetchFailures:     1,
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			server := newFakeEurekaServer(&Applications{})
+			defer server.Close()
+			if testCase.statusCode != 0 {
+				server.setStatusCode(testCase.statusCode)
+			}
+
+			clientProperties := newClientProperties()
+			clientProperties.ServiceUrl = map[string]string{DefaultZone: server.serviceUrl()}
+			instanceProperties := newValidInstanceProperties()
+
+			httpClient := NewDefaultHttpClient(server.serviceUrl())
+			provider := newDefaultInstanceInfoProvider(instanceProperties, *clientProperties, nil, nil)
+			registrar := newRegistrar(httpClient, newInstanceInfoManager(provider), *clientProperties, nil, nil)
+			registryCache := newRegistryCache(httpClient, *clientProperties, nil, nil)
+			_ = registrar.Renew()
+			_ = registryCache.Refresh()
+
+			stats := newClientStatsProvider(registrar, registryCache, *clientProperties).GetClientStats()
+			if stats.Registered != testCase.expectRegistered {
+				t.Errorf("expected registered %v, got %v", testCase.expectRegistered, stats.Registered)
+			}
+			if _, ok := stats.GetTimeSinceLastHeartbeat(); ok != testCase.expectHeartbeat {
+				t.Errorf("expected a successful heartbeat: %v", testCase.expectHeartbeat)
+			}
+			if _, ok := stats.GetTimeSinceLastFetch(); ok != testCase.expectFetch {
+				t.Errorf("expected a successful fetch: %v", testCase.expectFetch)
+			}
+			if stats.ConsecutiveHeartbeatFailures != testCase.expectedHeartbeatFailures {
+				t.Errorf("expected %d heartbeat failures, got %d", testCase.expectedHeartbeatFailures, stats.ConsecutiveHeartbeatFailures)
+			}
+			if stats.ConsecutiveFetchFailures != testCase.expectedFetchFailures {
+				t.Errorf("expected %d fetch failures, got %d", testCase.expectedFetchFailures, stats.ConsecutiveFetchFailures)
+			}
+		})
+	}
+}
+
+func TestClientStatsHealthIndicator_GetHealth(t *testing.T) {
+	testCases := []struct {
+		name               string
+		noComponents       bool
+		registryWithEureka bool
+		fetchRegistry      bool
+		renew              bool
+		refresh            bool
+		expected           InstanceStatus
+		expectedDetails    []string
+	}{
+		{
+			name:         "no components",
+			noComponents: true,
+			expected:     InstanceStatusUnknown,
+		},
+		{
+			name:     "registration and fetch disabled",
+			expected: InstanceStatusUp,
+		},
+		{
+			name:               "no heartbeat yet",
+			registryWithEureka: true,
+			expected:           InstanceStatusDown,
+			expectedDetails:    []string{"heartbeat"},
+		},
+		{
+			name:               "recent heartbeat",
+			registryWithEureka: true,
+			renew:              true,
+			expected:           InstanceStatusUp,
+		},
+		{
+			name:            "no fetch yet",
+			fetchRegistry:   true,
+			expected:        InstanceStatusDown,
+			expectedDetails: []string{"registry"},
+		},
+		{
+			name:          "recent fetch",
+			fetchRegistry: true,
+			refresh:       true,
+			expected:      InstanceStatusUp,
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			server := newFakeEurekaServer(&Applications{})
+			defer server.Close()
+
+			clientProperties := newClientProperties()
+			clientProperties.ServiceUrl = map[string]string{DefaultZone: server.serviceUrl()}
+			clientProperties.RegistryWithEureka = testCase.registryWithEureka
+			clientProperties.FetchRegistry = testCase.fetchRegistry
+			instanceProperties := newValidInstanceProperties()
+
+			httpClient := NewDefaultHttpClient(server.serviceUrl())
+			provider := newDefaultInstanceInfoProvider(instanceProperties, *clientProperties, nil, nil)
+			registrar := newRegistrar(httpClient, newInstanceInfoManager(provider), *clientProperties, nil, nil)
+			registryCache := newRegistryCache(httpClient, *clientProperties, nil, nil)
+			if testCase.renew {
+				if err := registrar.Renew(); err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+			}
+			if testCase.refresh {
+				if err := registryCache.Refresh(); err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+			}
+
+			statsProvider := newClientStatsProvider(registrar, registryCache, *clientProperties)
+			if testCase.noComponents {
+				statsProvider = newClientStatsProvider(nil, nil, *clientProperties)
+			}
+
+			health := NewClientStatsHealthIndicator(statsProvider, time.Minute, time.Minute).GetHealth()
+			if health.Status != testCase.expected {
+				t.Errorf("expected status %s, got %s: %v", testCase.expected, health.Status, health.Details)
+			}
+			for _, detail := range testCase.expectedDetails {
+				if _, ok := health.Details[detail]; !ok {
+					t.Errorf("expected the %s detail, got %v", detail, health.Details)
+				}
+			}
+		})
+	}
+}
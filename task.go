@@ -0,0 +1,120 @@
+package eureka
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	TaskHeartbeat    = "heartbeat"
+	TaskCacheRefresh = "cacheRefresh"
+
+	defaultMaxBackoffMultiplier = 10
+	defaultTaskPoolSize         = 2
+)
+
+type TaskOptions struct {
+	Timeout              time.Duration
+	MaxBackoffMultiplier int
+	PoolSize             int
+}
+
+type supervisedTask struct {
+	name     string
+	task     func(ctx context.Context) error
+	interval time.Duration
+	options  TaskOptions
+	metrics  MetricsRecorder
+	logger   Logger
+	running  int32
+}
+
+func newSupervisedTask(name string, task func(ctx context.Context) error, interval time.Duration, options TaskOptions, metrics MetricsRecorder, logger Logger) *supervisedTask {
+	if options.Timeout <= 0 {
+		options.Timeout = interval
+	}
+	if options.MaxBackoffMultiplier <= 0 {
+		options.MaxBackoffMultiplier = defaultMaxBackoffMultiplier
+	}
+	if options.PoolSize <= 0 {
+		options.PoolSize = defaultTaskPoolSize
+	}
+	return &supervisedTask{
+		name:     name,
+		task:     task,
+		interval: interval,
+		options:  options,
+		metrics:  metrics,
+		logger:   logger,
+	}
+}
+
+func (task *supervisedTask) run(stopCh chan struct{}) {
+	delay := task.interval
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	for {
+		select {
+		case <-stopCh:
+			return
+		case <-timer.C:
+			delay = task.execute(delay)
+			timer.Reset(delay)
+		}
+	}
+}
+
+func (task *supervisedTask) execute(delay time.Duration) time.Duration {
+	fields := Fields{
+		FieldTask: task.name,
+	}
+
+	// the runs which timed out are still holding their workers, no more runs are started until they finish
+	if atomic.LoadInt32(&task.running) >= int32(task.options.PoolSize) {
+		task.report(ResultRejected, delay)
+		task.logger.Warning("task run rejected, the previous runs are still in progress", fields)
+		return delay
+	}
+
+	// the run is cancelled when it times out, so its requests give up and free the worker
+	ctx, cancel := context.WithTimeout(context.Background(), task.options.Timeout)
+	defer cancel()
+
+	atomic.AddInt32(&task.running, 1)
+	done := make(chan error, 1)
+	go func() {
+		defer atomic.AddInt32(&task.running, -1)
+		done <- task.task(ctx)
+	}()
+
+	select {
+	case err := <-done:
+		result := ResultSuccess
+		if err != nil {
+			result = ResultFailure
+		}
+		task.report(result, task.interval)
+		return task.interval
+	case <-ctx.Done():
+		// a slow server is given more time with every timeout, up to the max backoff multiplier
+		maxDelay := task.interval * time.Duration(task.options.MaxBackoffMultiplier)
+		delay *= 2
+		if delay > maxDelay {
+			delay = maxDelay
+		}
+		task.report(ResultTimeout, delay)
+		task.logger.Warning("task run timed out", fields.with(FieldDelay, delay.String()))
+		return delay
+	}
+}
+
+func (task *supervisedTask) report(result string, delay time.Duration) {
+	task.metrics.SetGauge(MetricTaskDelay, delay.Seconds(), map[string]string{
+		LabelTask: task.name,
+	})
+	task.metrics.IncrementCounter(MetricTaskRuns, map[string]string{
+		LabelTask:   task.name,
+		LabelResult: result,
+	})
+}
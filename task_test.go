@@ -0,0 +1,126 @@
+package eureka
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+type taskResultRecorder struct {
+	NoOpMetricsRecorder
+	results []string
+}
+
+func (recorder *taskResultRecorder) IncrementCounter(name string, labels map[string]string) {
+	if name == MetricTaskRuns {
+		recorder.results = append(recorder.results, labels[LabelResult])
+	}
+}
+
+func TestSupervisedTask_Execute(t *testing.T) {
+	const interval = 20 * time.Millisecond
+
+	testCases := []struct {
+		name           string
+		task           func(ctx context.Context) error
+		delay          time.Duration
+		expectedDelay  time.Duration
+		expectedResult string
+	}{
+		{
+			name:           "success resets the delay",
+			task:           func(ctx context.Context) error { return nil },
+			delay:          4 * interval,
+			expectedDelay:  interval,
+			expectedResult: ResultSuccess,
+		},
+		{
+			name:           "failure resets the delay",
+			task:           func(ctx context.Context) error { return errors.New("failed") },
+			delay:          4 * interval,
+			expectedDelay:  interval,
+			expectedResult: ResultFailure,
+		},
+		{
+			name: "timeout doubles the delay",
+			task: func(ctx context.Context) error {
+				<-ctx.Done()
+				return ctx.Err()
+			},
+			delay:          interval,
+			expectedDelay:  2 * interval,
+			expectedResult: ResultTimeout,
+		},
+		{
+			name: "timeout is capped by the max backoff multiplier",
+			task: func(ctx context.Context) error {
+				<-ctx.Done()
+				return ctx.Err()
+			},
+			delay:          2 * interval,
+			expectedDelay:  3 * interval,
+			expectedResult: ResultTimeout,
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			recorder := &taskResultRecorder{}
+			task := newSupervisedTask("test", testCase.task, interval, TaskOptions{
+				MaxBackoffMultiplier: 3,
+			}, recorder, NoOpLogger{})
+
+			if delay := task.execute(testCase.delay); delay != testCase.expectedDelay {
+				t.Errorf("expected delay %s, got %s", testCase.expectedDelay, delay)
+			}
+			if len(recorder.results) != 1 || recorder.results[0] != testCase.expectedResult {
+				t.Errorf("expected result %s, got %v", testCase.expectedResult, recorder.results)
+			}
+		})
+	}
+}
+
+func TestSupervisedTask_TimeoutCancelsTheRun(t *testing.T) {
+	cancelled := make(chan struct{})
+	task := newSupervisedTask("test", func(ctx context.Context) error {
+		<-ctx.Done()
+		close(cancelled)
+		return ctx.Err()
+	}, time.Second, TaskOptions{
+		Timeout: 10 * time.Millisecond,
+	}, NoOpMetricsRecorder{}, NoOpLogger{})
+
+	task.execute(time.Second)
+
+	select {
+	case <-cancelled:
+	case <-time.After(time.Second):
+		t.Fatalf("the timed out run was not cancelled")
+	}
+}
+
+func TestSupervisedTask_RejectsWhenThePoolIsFull(t *testing.T) {
+	release := make(chan struct{})
+	defer close(release)
+
+	recorder := &taskResultRecorder{}
+	// the run ignores its context and keeps holding its worker
+	task := newSupervisedTask("test", func(ctx context.Context) error {
+		<-release
+		return nil
+	}, time.Second, TaskOptions{
+		Timeout:  10 * time.Millisecond,
+		PoolSize: 1,
+	}, recorder, NoOpLogger{})
+
+	task.execute(time.Second)
+	if delay := task.execute(time.Second); delay != time.Second {
+		t.Errorf("expected the delay to be kept, got %s", delay)
+	}
+
+	expected := []string{ResultTimeout, ResultRejected}
+	if len(recorder.results) != len(expected) || recorder.results[0] != expected[0] || recorder.results[1] != expected[1] {
+		t.Errorf("expected results %v, got %v", expected, recorder.results)
+	}
+}
@@ -0,0 +1,187 @@
+package eureka
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	TraceParentHeader = "traceparent"
+
+	AttributeHttpMethod      = "http.method"
+	AttributeHttpUrl         = "http.url"
+	AttributeHttpStatusCode  = "http.status_code"
+	AttributeEurekaOperation = "eureka.operation"
+	AttributeEurekaEndpoint  = "eureka.endpoint"
+	AttributeEurekaApp       = "eureka.app"
+)
+
+type traceParentKey struct {
+}
+
+func ContextWithTraceParent(ctx context.Context, traceParent string) context.Context {
+	return context.WithValue(ctx, traceParentKey{}, traceParent)
+}
+
+func TraceParentFromContext(ctx context.Context) string {
+	if ctx == nil {
+		return ""
+	}
+	traceParent, _ := ctx.Value(traceParentKey{}).(string)
+	return traceParent
+}
+
+type Span interface {
+	SetAttribute(key string, value interface{})
+	RecordError(err error)
+	TraceParent() string
+	End()
+}
+
+type Tracer interface {
+	StartSpan(ctx context.Context, name string) (context.Context, Span)
+}
+
+type NoOpTracer struct {
+}
+
+func (tracer NoOpTracer) StartSpan(ctx context.Context, name string) (context.Context, Span) {
+	return ctx, noOpSpan{
+		traceParent: TraceParentFromContext(ctx),
+	}
+}
+
+type noOpSpan struct {
+	traceParent string
+}
+
+func (span noOpSpan) SetAttribute(key string, value interface{}) {
+
+}
+
+func (span noOpSpan) RecordError(err error) {
+
+}
+
+func (span noOpSpan) TraceParent() string {
+	return span.traceParent
+}
+
+func (span noOpSpan) End() {
+
+}
+
+type RecordedSpan struct {
+	Name         string
+	TraceId      string
+	SpanId       string
+	ParentSpanId string
+	Attributes   map[string]interface{}
+	Errors       []error
+	StartTime    time.Time
+	EndTime      time.Time
+}
+
+// InMemoryTracer keeps every ended span, it is meant to be used in tests.
+type InMemoryTracer struct {
+	spans   []RecordedSpan
+	spansMu sync.Mutex
+}
+
+func NewInMemoryTracer() *InMemoryTracer {
+	return &InMemoryTracer{
+		spans: make([]RecordedSpan, 0),
+	}
+}
+
+func (tracer *InMemoryTracer) StartSpan(ctx context.Context, name string) (context.Context, Span) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	span := &inMemorySpan{
+		tracer: tracer,
+		recordedSpan: RecordedSpan{
+			Name:       name,
+			SpanId:     randomHex(8),
+			Attributes: make(map[string]interface{}),
+			StartTime:  time.Now(),
+		},
+	}
+
+	traceId, parentSpanId, ok := parseTraceParent(TraceParentFromContext(ctx))
+	if ok {
+		span.recordedSpan.TraceId = traceId
+		span.recordedSpan.ParentSpanId = parentSpanId
+	} else {
+		span.recordedSpan.TraceId = randomHex(16)
+	}
+
+	return ContextWithTraceParent(ctx, span.TraceParent()), span
+}
+
+func (tracer *InMemoryTracer) GetSpans() []RecordedSpan {
+	tracer.spansMu.Lock()
+	defer tracer.spansMu.Unlock()
+	return append([]RecordedSpan(nil), tracer.spans...)
+}
+
+func (tracer *InMemoryTracer) Reset() {
+	tracer.spansMu.Lock()
+	defer tracer.spansMu.Unlock()
+	tracer.spans = make([]RecordedSpan, 0)
+}
+
+type inMemorySpan struct {
+	tracer       *InMemoryTracer
+	recordedSpan RecordedSpan
+	spanMu       sync.Mutex
+}
+
+func (span *inMemorySpan) SetAttribute(key string, value interface{}) {
+	span.spanMu.Lock()
+	defer span.spanMu.Unlock()
+	span.recordedSpan.Attributes[key] = value
+}
+
+func (span *inMemorySpan) RecordError(err error) {
+	if err == nil {
+		return
+	}
+	span.spanMu.Lock()
+	defer span.spanMu.Unlock()
+	span.recordedSpan.Errors = append(span.recordedSpan.Errors, err)
+}
+
+func (span *inMemorySpan) TraceParent() string {
+	return "00-" + span.recordedSpan.TraceId + "-" + span.recordedSpan.SpanId + "-01"
+}
+
+func (span *inMemorySpan) End() {
+	span.spanMu.Lock()
+	span.recordedSpan.EndTime = time.Now()
+	recordedSpan := span.recordedSpan
+	span.spanMu.Unlock()
+
+	span.tracer.spansMu.Lock()
+	defer span.tracer.spansMu.Unlock()
+	span.tracer.spans = append(span.tracer.spans, recordedSpan)
+}
+
+func parseTraceParent(traceParent string) (traceId string, spanId string, ok bool) {
+	parts := strings.Split(traceParent, "-")
+	if len(parts) != 4 || len(parts[1]) != 32 || len(parts[2]) != 16 {
+		return "", "", false
+	}
+	return parts[1], parts[2], true
+}
+
+func randomHex(size int) string {
+	bytes := make([]byte, size)
+	_, _ = rand.Read(bytes)
+	return hex.EncodeToString(bytes)
+}
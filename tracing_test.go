@@ -0,0 +1,89 @@
+package eureka
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestDefaultHttpClient_TraceParentPerCall(t *testing.T) {
+	var traceParents []string
+	var traceParentsMu sync.Mutex
+	server := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		traceParentsMu.Lock()
+		traceParents = append(traceParents, request.Header.Get(TraceParentHeader))
+		traceParentsMu.Unlock()
+		writer.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	tracer := NewInMemoryTracer()
+	httpClient := NewDefaultHttpClient(server.URL + "/eureka/").WithTracer(tracer)
+
+	cancelledCtx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	testCases := []struct {
+		name          string
+		ctx           context.Context
+		parentTraceId string
+		expectErr     bool
+	}{
+		{
+			name:          "first parent",
+			ctx:           ContextWithTraceParent(context.Background(), "00-11111111111111111111111111111111-1111111111111111-01"),
+			parentTraceId: "11111111111111111111111111111111",
+		},
+		{
+			name:      "cancelled call",
+			ctx:       cancelledCtx,
+			expectErr: true,
+		},
+		{
+			name:          "second parent after a cancelled call",
+			ctx:           ContextWithTraceParent(context.Background(), "00-22222222222222222222222222222222-2222222222222222-01"),
+			parentTraceId: "22222222222222222222222222222222",
+		},
+		{
+			name: "no parent",
+			ctx:  context.Background(),
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			tracer.Reset()
+			traceParentsMu.Lock()
+			traceParents = nil
+			traceParentsMu.Unlock()
+
+			err := httpClient.DeregisterContext(testCase.ctx, "TEST", "instance")
+			if (err != nil) != testCase.expectErr {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			spans := tracer.GetSpans()
+			if len(spans) != 1 {
+				t.Fatalf("expected a single span, got %d", len(spans))
+			}
+			if testCase.parentTraceId != "" && spans[0].TraceId != testCase.parentTraceId {
+				t.Errorf("expected trace id %s, got %s", testCase.parentTraceId, spans[0].TraceId)
+			}
+
+			traceParentsMu.Lock()
+			defer traceParentsMu.Unlock()
+			if testCase.expectErr {
+				if len(traceParents) != 0 {
+					t.Errorf("expected no request, got %d", len(traceParents))
+				}
+				return
+			}
+			if len(traceParents) != 1 || !strings.Contains(traceParents[0], spans[0].TraceId+"-"+spans[0].SpanId) {
+				t.Errorf("expected the trace parent of the span, got %v", traceParents)
+			}
+		})
+	}
+}
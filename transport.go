@@ -0,0 +1,43 @@
+package eureka
+
+import (
+	"net/http"
+	"sync"
+)
+
+type refreshableTransport struct {
+	transport   http.RoundTripper
+	transportMu sync.RWMutex
+}
+
+func newRefreshableTransport() *refreshableTransport {
+	return &refreshableTransport{
+		transport: newTransport(),
+	}
+}
+
+func newTransport() http.RoundTripper {
+	if defaultTransport, ok := http.DefaultTransport.(*http.Transport); ok {
+		return defaultTransport.Clone()
+	}
+	return http.DefaultTransport
+}
+
+func (transport *refreshableTransport) RoundTrip(request *http.Request) (*http.Response, error) {
+	transport.transportMu.RLock()
+	current := transport.transport
+	transport.transportMu.RUnlock()
+	return current.RoundTrip(request)
+}
+
+func (transport *refreshableTransport) Rebuild() {
+	transport.transportMu.Lock()
+	previous := transport.transport
+	transport.transport = newTransport()
+	transport.transportMu.Unlock()
+
+	// the requests in flight keep their connections, only the idle ones to the old servers are dropped
+	if closer, ok := previous.(interface{ CloseIdleConnections() }); ok {
+		closer.CloseIdleConnections()
+	}
+}
@@ -0,0 +1,198 @@
+package eureka
+
+import (
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+)
+
+const maxPort = 65535
+
+// Validatable is implemented by the components which check their properties
+// before they are used.
+type Validatable interface {
+	Validate() error
+}
+
+type ValidationError struct {
+	Problems []string
+}
+
+func (validationError *ValidationError) Error() string {
+	return "eureka: invalid configuration: " + strings.Join(validationError.Problems, "; ")
+}
+
+type validator struct {
+	prefix   string
+	problems []string
+}
+
+func (validator *validator) addProblem(format string, args ...interface{}) {
+	validator.problems = append(validator.problems, validator.prefix+"."+fmt.Sprintf(format, args...))
+}
+
+func (validator *validator) checkUrl(name, value string) {
+	parsedUrl, err := url.Parse(value)
+	if err != nil || (parsedUrl.Scheme != "http" && parsedUrl.Scheme != "https") || parsedUrl.Host == "" {
+		validator.addProblem("%s is not a valid url: %q", name, value)
+	}
+}
+
+func (validator *validator) checkPort(name string, port int) {
+	if port <= 0 || port > maxPort {
+		validator.addProblem("%s is out of range: %d", name, port)
+	}
+}
+
+func (validator *validator) checkListenerPort(name string, port int) {
+	// 0 stands for an ephemeral port, the application reports the bound one later
+	if port < 0 || port > maxPort {
+		validator.addProblem("%s is out of range: %d", name, port)
+	}
+}
+
+func (validator *validator) checkNotNegative(name string, value int) {
+	if value < 0 {
+		validator.addProblem("%s must not be negative: %d", name, value)
+	}
+}
+
+func (validator *validator) checkPositive(name string, value int) {
+	if value <= 0 {
+		validator.addProblem("%s must be positive: %d", name, value)
+	}
+}
+
+func (validator *validator) err() error {
+	if len(validator.problems) == 0 {
+		return nil
+	}
+	return &ValidationError{
+		Problems: validator.problems,
+	}
+}
+
+func (clientConfiguration *ClientProperties) Validate() error {
+	validator := &validator{prefix: clientConfiguration.GetConfigurationPrefix()}
+
+	zones := make([]string, 0, len(clientConfiguration.ServiceUrl))
+	for zone := range clientConfiguration.ServiceUrl {
+		zones = append(zones, zone)
+	}
+	sort.Strings(zones)
+	for _, zone := range zones {
+		for _, serviceUrl := range clientConfiguration.splitValues(clientConfiguration.ServiceUrl[zone]) {
+			validator.checkUrl("serviceUrl."+zone, serviceUrl)
+		}
+	}
+
+	if clientConfiguration.UseDnsForFetchingServiceUrls {
+		if clientConfiguration.EurekaServerDnsName == "" {
+			validator.addProblem("eurekaServerDnsName is required to use dns for fetching service urls")
+		}
+		validator.checkPort("eurekaServerPort", clientConfiguration.EurekaServerPort)
+	}
+
+	if clientConfiguration.SelfPreservationThresholdPercent < 0 || clientConfiguration.SelfPreservationThresholdPercent > 100 {
+		validator.addProblem("selfPreservationThresholdPercent must be between 0 and 100: %d", clientConfiguration.SelfPreservationThresholdPercent)
+	}
+
+	validator.checkNotNegative("registryFetchIntervalSeconds", clientConfiguration.RegistryFetchIntervalSeconds)
+	validator.checkNotNegative("fallbackThresholdSeconds", clientConfiguration.FallbackThresholdSeconds)
+	validator.checkNotNegative("selfPreservationGracePeriodSeconds", clientConfiguration.SelfPreservationGracePeriodSeconds)
+	validator.checkNotNegative("eurekaServiceUrlPollIntervalSeconds", clientConfiguration.EurekaServiceUrlPollIntervalSeconds)
+	validator.checkNotNegative("instanceInfoReplicationIntervalSeconds", clientConfiguration.InstanceInfoReplicationIntervalSeconds)
+	// the rate limiter would never refill after the first burst
+	validator.checkPositive("onDemandUpdateRatePerMinute", clientConfiguration.OnDemandUpdateRatePerMinute)
+	validator.checkNotNegative("onDemandUpdateBurstSize", clientConfiguration.OnDemandUpdateBurstSize)
+	validator.checkNotNegative("heartbeatTimeoutSeconds", clientConfiguration.HeartbeatTimeoutSeconds)
+	validator.checkNotNegative("heartbeatMaxBackoffMultiplier", clientConfiguration.HeartbeatMaxBackoffMultiplier)
+	validator.checkNotNegative("heartbeatExecutorPoolSize", clientConfiguration.HeartbeatExecutorPoolSize)
+	validator.checkNotNegative("cacheRefreshTimeoutSeconds", clientConfiguration.CacheRefreshTimeoutSeconds)
+	validator.checkNotNegative("cacheRefreshMaxBackoffMultiplier", clientConfiguration.CacheRefreshMaxBackoffMultiplier)
+	validator.checkNotNegative("cacheRefreshExecutorPoolSize", clientConfiguration.CacheRefreshExecutorPoolSize)
+
+	serviceIds := make([]string, 0, len(clientConfiguration.FallbackInstances))
+	for serviceId := range clientConfiguration.FallbackInstances {
+		serviceIds = append(serviceIds, serviceId)
+	}
+	sort.Strings(serviceIds)
+	for _, serviceId := range serviceIds {
+		for index, fallbackInstance := range clientConfiguration.FallbackInstances[serviceId] {
+			name := fmt.Sprintf("fallbackInstances.%s[%d]", serviceId, index)
+			if fallbackInstance.Host == "" {
+				validator.addProblem("%s.host is required", name)
+			}
+			validator.checkPort(name+".port", fallbackInstance.Port)
+		}
+	}
+	return validator.err()
+}
+
+func (instanceProperties *InstanceProperties) Validate() error {
+	validator := &validator{prefix: instanceProperties.GetConfigurationPrefix()}
+
+	for _, problem := range instanceProperties.initializationProblems {
+		validator.addProblem("%s", problem)
+	}
+
+	if instanceProperties.ApplicationName == "" || instanceProperties.ApplicationName == unknown {
+		validator.addProblem("appName is missing, set procyon.application.name")
+	}
+
+	if instanceProperties.NonSecurePortEnabled {
+		validator.checkListenerPort("nonSecurePort", instanceProperties.NonSecurePort)
+	}
+
+	if instanceProperties.SecurePortEnabled {
+		validator.checkListenerPort("securePort", instanceProperties.SecurePort)
+	}
+
+	if !instanceProperties.NonSecurePortEnabled && !instanceProperties.SecurePortEnabled {
+		validator.addProblem("nonSecurePortEnabled or securePortEnabled must be true")
+	}
+
+	if instanceProperties.LeaseRenewalIntervalInSeconds <= 0 {
+		validator.addProblem("leaseRenewalIntervalInSeconds must be positive: %d", instanceProperties.LeaseRenewalIntervalInSeconds)
+	}
+
+	if instanceProperties.LeaseExpirationDurationInSeconds <= 0 {
+		validator.addProblem("leaseExpirationDurationInSeconds must be positive: %d", instanceProperties.LeaseExpirationDurationInSeconds)
+	} else if instanceProperties.LeaseExpirationDurationInSeconds <= instanceProperties.LeaseRenewalIntervalInSeconds {
+		// the lease would expire before the next heartbeat renews it
+		validator.addProblem("leaseExpirationDurationInSeconds must be greater than leaseRenewalIntervalInSeconds: %d",
+			instanceProperties.LeaseExpirationDurationInSeconds)
+	}
+
+	switch instanceProperties.InstanceIdStrategy {
+	case "", InstanceIdStrategyHostAppPort, InstanceIdStrategyRandom, InstanceIdStrategyIp, InstanceIdStrategyPod:
+	case InstanceIdStrategyTemplate:
+		if instanceProperties.InstanceIdTemplate == "" {
+			validator.addProblem("instanceIdTemplate is required for the template instance id strategy")
+		}
+	default:
+		validator.addProblem("instanceIdStrategy is not supported: %q", instanceProperties.InstanceIdStrategy)
+	}
+
+	if instanceProperties.DataCenterInfo.Name == DataCenterAmazon {
+		validator.checkUrl("amazonMetadataUrl", instanceProperties.AmazonMetadataUrl)
+	}
+	return validator.err()
+}
+
+func validateProperties(clientProperties ClientProperties, instanceProperties InstanceProperties) error {
+	problems := make([]string, 0)
+	for _, err := range []error{clientProperties.Validate(), instanceProperties.Validate()} {
+		if validationError, ok := err.(*ValidationError); ok {
+			problems = append(problems, validationError.Problems...)
+		}
+	}
+
+	if len(problems) == 0 {
+		return nil
+	}
+	return &ValidationError{
+		Problems: problems,
+	}
+}
@@ -0,0 +1,114 @@
+package eureka
+
+import (
+	"reflect"
+	"testing"
+)
+
+func newValidInstanceProperties() InstanceProperties {
+	return InstanceProperties{
+		ApplicationName:                  "orders",
+		NonSecurePort:                    8080,
+		NonSecurePortEnabled:             true,
+		LeaseRenewalIntervalInSeconds:    30,
+		LeaseExpirationDurationInSeconds: 90,
+		InstanceIdStrategy:               InstanceIdStrategyHostAppPort,
+	}
+}
+
+func TestValidateProperties(t *testing.T) {
+	testCases := []struct {
+		name               string
+		clientProperties   func(clientProperties *ClientProperties)
+		instanceProperties func(instanceProperties *InstanceProperties)
+		expected           []string
+	}{
+		{
+			name: "valid properties",
+		},
+		{
+			name: "client problems",
+			clientProperties: func(clientProperties *ClientProperties) {
+				clientProperties.ServiceUrl = map[string]string{DefaultZone: "localhost:8761"}
+				clientProperties.OnDemandUpdateRatePerMinute = 0
+			},
+			expected: []string{
+				`procyon.cloud.eureka.client.serviceUrl.defaultZone is not a valid url: "localhost:8761"`,
+				"procyon.cloud.eureka.client.onDemandUpdateRatePerMinute must be positive: 0",
+			},
+		},
+		{
+			name: "instance problems",
+			instanceProperties: func(instanceProperties *InstanceProperties) {
+				instanceProperties.initializationProblems = []string{"server.port is not a valid port: http"}
+				instanceProperties.NonSecurePort = -1
+				instanceProperties.InstanceIdStrategy = "unknown"
+			},
+			expected: []string{
+				"procyon.cloud.eureka.instance.server.port is not a valid port: http",
+				"procyon.cloud.eureka.instance.nonSecurePort is out of range: -1",
+				`procyon.cloud.eureka.instance.instanceIdStrategy is not supported: "unknown"`,
+			},
+		},
+		{
+			name: "client and instance problems are aggregated",
+			clientProperties: func(clientProperties *ClientProperties) {
+				clientProperties.UseDnsForFetchingServiceUrls = true
+			},
+			instanceProperties: func(instanceProperties *InstanceProperties) {
+				instanceProperties.LeaseExpirationDurationInSeconds = 30
+			},
+			expected: []string{
+				"procyon.cloud.eureka.client.eurekaServerDnsName is required to use dns for fetching service urls",
+				"procyon.cloud.eureka.instance.leaseExpirationDurationInSeconds must be greater than leaseRenewalIntervalInSeconds: 30",
+			},
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			clientProperties := newClientProperties()
+			if testCase.clientProperties != nil {
+				testCase.clientProperties(clientProperties)
+			}
+			instanceProperties := newValidInstanceProperties()
+			if testCase.instanceProperties != nil {
+				testCase.instanceProperties(&instanceProperties)
+			}
+
+			err := validateProperties(*clientProperties, instanceProperties)
+			if testCase.expected == nil {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+
+			validationError, ok := err.(*ValidationError)
+			if !ok {
+				t.Fatalf("expected a validation error, got %v", err)
+			}
+			if !reflect.DeepEqual(validationError.Problems, testCase.expected) {
+				t.Errorf("expected %q, got %q", testCase.expected, validationError.Problems)
+			}
+		})
+	}
+}
+
+func TestRegistryCache_InitializePea(t *testing.T) {
+	clientProperties := newClientProperties()
+	cache := newRegistryCache(nil, *clientProperties, nil, nil)
+	if err := cache.InitializePea(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	clientProperties.OnDemandUpdateRatePerMinute = 0
+	cache.SetClientProperties(*clientProperties)
+	if _, ok := cache.InitializePea().(*ValidationError); !ok {
+		t.Errorf("expected a validation error")
+	}
+	// the cache is not started with invalid properties
+	if _, ok := cache.Start().(*ValidationError); !ok {
+		t.Errorf("expected a validation error")
+	}
+}
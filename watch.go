@@ -0,0 +1,208 @@
+package eureka
+
+import (
+	"encoding/json"
+	"net/http"
+	"sync"
+	"time"
+)
+
+type Health struct {
+	Status  InstanceStatus         `json:"status"`
+	Details map[string]interface{} `json:"details,omitempty"`
+}
+
+type HealthIndicator interface {
+	GetName() string
+	GetHealth() Health
+}
+
+type LeaseDetails struct {
+	RenewalIntervalInSecs int `json:"renewalIntervalInSecs"`
+	DurationInSecs        int `json:"durationInSecs"`
+}
+
+type WatchInfo struct {
+	Instance      *InstanceInfo `json:"instance"`
+	Registered    bool          `json:"registered"`
+	LastHeartbeat *time.Time    `json:"lastHeartbeat,omitempty"`
+	Lease         *LeaseDetails `json:"lease,omitempty"`
+}
+
+type WatchHealth struct {
+	Status     InstanceStatus    `json:"status"`
+	Components map[string]Health `json:"components,omitempty"`
+}
+
+type WatchHandler struct {
+	instanceInfoProvider InstanceInfoProvider
+	registrar            *Registrar
+	statusPagePath       string
+	healthCheckPath      string
+	healthIndicators     []HealthIndicator
+	healthIndicatorsMu   sync.RWMutex
+}
+
+func newWatchHandler(instanceInfoManager *InstanceInfoManager, registrar *Registrar, instanceProperties InstanceProperties) *WatchHandler {
+	watchHandler := &WatchHandler{
+		instanceInfoProvider: instanceInfoManager,
+		registrar:            registrar,
+		statusPagePath:       statusPageUrlPath,
+		healthCheckPath:      healthCheckUrlPath,
+		healthIndicators:     make([]HealthIndicator, 0),
+	}
+
+	// the handler answers on the same paths the instance advertises
+	if instanceProperties.StatusPageUrl != "" {
+		watchHandler.statusPagePath = instanceProperties.StatusPageUrl
+	}
+	if instanceProperties.HealthCheckUrl != "" {
+		watchHandler.healthCheckPath = instanceProperties.HealthCheckUrl
+	}
+	watchHandler.AddHealthIndicator(registrationHealthIndicator{registrar})
+	return watchHandler
+}
+
+func (watchHandler *WatchHandler) AddHealthIndicator(healthIndicator HealthIndicator) {
+	watchHandler.healthIndicatorsMu.Lock()
+	defer watchHandler.healthIndicatorsMu.Unlock()
+	if healthIndicator != nil {
+		watchHandler.healthIndicators = append(watchHandler.healthIndicators, healthIndicator)
+	}
+}
+
+func (watchHandler *WatchHandler) ServeHTTP(writer http.ResponseWriter, request *http.Request) {
+	if request.Method != http.MethodGet && request.Method != http.MethodHead {
+		writer.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	switch request.URL.Path {
+	case watchHandler.getStatusPagePath():
+		watchHandler.writeJson(writer, http.StatusOK, watchHandler.GetInfo())
+	case watchHandler.getHealthCheckPath():
+		health := watchHandler.GetHealth()
+		statusCode := http.StatusOK
+		if health.Status == InstanceStatusDown || health.Status == InstanceStatusOutOfService {
+			statusCode = http.StatusServiceUnavailable
+		}
+		watchHandler.writeJson(writer, statusCode, health)
+	default:
+		writer.WriteHeader(http.StatusNotFound)
+	}
+}
+
+func (watchHandler *WatchHandler) getStatusPagePath() string {
+	if watchHandler.statusPagePath == "" {
+		return statusPageUrlPath
+	}
+	return watchHandler.statusPagePath
+}
+
+func (watchHandler *WatchHandler) getHealthCheckPath() string {
+	if watchHandler.healthCheckPath == "" {
+		return healthCheckUrlPath
+	}
+	return watchHandler.healthCheckPath
+}
+
+func (watchHandler *WatchHandler) GetInfo() WatchInfo {
+	watchInfo := WatchInfo{}
+	if watchHandler.instanceInfoProvider == nil {
+		return watchInfo
+	}
+
+	instanceInfo := watchHandler.instanceInfoProvider.GetInstanceInfo()
+	watchInfo.Instance = instanceInfo
+
+	if watchHandler.registrar != nil {
+		watchInfo.Registered = watchHandler.registrar.IsRegistered()
+		if lastHeartbeat := watchHandler.registrar.GetLastSuccessfulHeartbeat(); !lastHeartbeat.IsZero() {
+			watchInfo.LastHeartbeat = &lastHeartbeat
+		}
+	}
+
+	if instanceInfo.LeaseInfo != nil {
+		watchInfo.Lease = &LeaseDetails{
+			RenewalIntervalInSecs: instanceInfo.LeaseInfo.RenewalIntervalInSecs,
+			DurationInSecs:        instanceInfo.LeaseInfo.DurationInSecs,
+		}
+	}
+	return watchInfo
+}
+
+func (watchHandler *WatchHandler) GetHealth() WatchHealth {
+	watchHandler.healthIndicatorsMu.RLock()
+	healthIndicators := append([]HealthIndicator(nil), watchHandler.healthIndicators...)
+	watchHandler.healthIndicatorsMu.RUnlock()
+
+	watchHealth := WatchHealth{
+		Status:     InstanceStatusUnknown,
+		Components: make(map[string]Health),
+	}
+
+	statuses := make([]InstanceStatus, 0, len(healthIndicators))
+	for _, healthIndicator := range healthIndicators {
+		health := healthIndicator.GetHealth()
+		watchHealth.Components[healthIndicator.GetName()] = health
+		statuses = append(statuses, health.Status)
+	}
+	watchHealth.Status = aggregateStatus(statuses)
+	return watchHealth
+}
+
+func (watchHandler *WatchHandler) writeJson(writer http.ResponseWriter, statusCode int, body interface{}) {
+	writer.Header().Set("Content-Type", "application/json")
+	writer.WriteHeader(statusCode)
+	_ = json.NewEncoder(writer).Encode(body)
+}
+
+// the first status in the order wins, just like the way spring does
+var statusOrder = []InstanceStatus{
+	InstanceStatusDown,
+	InstanceStatusOutOfService,
+	InstanceStatusUp,
+	InstanceStatusUnknown,
+}
+
+func aggregateStatus(statuses []InstanceStatus) InstanceStatus {
+	for _, orderedStatus := range statusOrder {
+		for _, status := range statuses {
+			if status == orderedStatus {
+				return orderedStatus
+			}
+		}
+	}
+	return InstanceStatusUnknown
+}
+
+type registrationHealthIndicator struct {
+	registrar *Registrar
+}
+
+func (healthIndicator registrationHealthIndicator) GetName() string {
+	return "eureka"
+}
+
+func (healthIndicator registrationHealthIndicator) GetHealth() Health {
+	if healthIndicator.registrar == nil {
+		return Health{
+			Status: InstanceStatusUnknown,
+		}
+	}
+
+	health := Health{
+		Status:  InstanceStatusUp,
+		Details: make(map[string]interface{}),
+	}
+
+	if !healthIndicator.registrar.IsRegistered() {
+		health.Status = InstanceStatusUnknown
+	}
+
+	health.Details["registered"] = healthIndicator.registrar.IsRegistered()
+	if lastHeartbeat := healthIndicator.registrar.GetLastSuccessfulHeartbeat(); !lastHeartbeat.IsZero() {
+		health.Details["lastHeartbeat"] = lastHeartbeat
+	}
+	return health
+}
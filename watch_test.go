@@ -0,0 +1,60 @@
+package eureka
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestWatchHandler_ServeHTTP(t *testing.T) {
+	testCases := []struct {
+		name               string
+		instanceProperties InstanceProperties
+		path               string
+		expected           int
+	}{
+		{
+			name:     "default status page",
+			path:     statusPageUrlPath,
+			expected: http.StatusOK,
+		},
+		{
+			name: "configured status page",
+			instanceProperties: InstanceProperties{
+				StatusPageUrl: "/actuator/info",
+			},
+			path:     "/actuator/info",
+			expected: http.StatusOK,
+		},
+		{
+			name: "default status page is not served once configured",
+			instanceProperties: InstanceProperties{
+				StatusPageUrl: "/actuator/info",
+			},
+			path:     statusPageUrlPath,
+			expected: http.StatusNotFound,
+		},
+		{
+			name: "configured health check",
+			instanceProperties: InstanceProperties{
+				HealthCheckUrl: "/actuator/health",
+			},
+			path:     "/actuator/health",
+			expected: http.StatusOK,
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			watchHandler := newWatchHandler(nil, nil, testCase.instanceProperties)
+			// a nil manager is not a nil provider, the info is left out without it
+			watchHandler.instanceInfoProvider = nil
+
+			recorder := httptest.NewRecorder()
+			watchHandler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, testCase.path, nil))
+			if recorder.Code != testCase.expected {
+				t.Errorf("expected %d, got %d", testCase.expected, recorder.Code)
+			}
+		})
+	}
+}